@@ -3,14 +3,24 @@ package app
 import (
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/IPA-CyberLab/latest/cmd/latest/cache"
+	"github.com/IPA-CyberLab/latest/cmd/latest/changelog"
+	configcmd "github.com/IPA-CyberLab/latest/cmd/latest/config"
 	"github.com/IPA-CyberLab/latest/cmd/latest/list"
+	"github.com/IPA-CyberLab/latest/cmd/latest/providers"
 	"github.com/IPA-CyberLab/latest/cmd/latest/query"
 	"github.com/IPA-CyberLab/latest/cmd/latest/serve"
+	"github.com/IPA-CyberLab/latest/cmd/latest/snapshot"
+	"github.com/IPA-CyberLab/latest/pkg/config"
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
 	"github.com/IPA-CyberLab/latest/version"
 )
 
@@ -26,8 +36,18 @@ func New() *cli.App {
 		query.Command,
 		list.Command,
 		serve.Command,
+		changelog.Command,
+		providers.Command,
+		configcmd.Command,
+		cache.Command,
+		snapshot.Command,
 	}
 	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "Read the configuration from `PATH`. Defaults to latest/latest.yaml in $XDG_CONFIG_HOME or $XDG_CONFIG_DIRS. Flags and environment variables take precedence over it.",
+			EnvVars: []string{"LATEST_CONFIG"},
+		},
 		&cli.BoolFlag{
 			Name:  "log-location",
 			Usage: "Annotate logs with code location where the log was output",
@@ -40,6 +60,155 @@ func New() *cli.App {
 			Name:  "verbose",
 			Usage: "Enable verbose output",
 		},
+		&cli.StringFlag{
+			Name:    "m2-settings",
+			Usage:   "Read Maven repositories, mirrors and credentials from settings.xml at `PATH`",
+			Value:   fetch.DefaultMavenSettingsPath(),
+			EnvVars: []string{"LATEST_M2_SETTINGS"},
+		},
+		&cli.StringFlag{
+			Name:    "m2-repositories",
+			Usage:   "Comma separated list of `ID=URL` Maven repositories to query. Overrides --m2-settings. Credentials are read from $LATEST_M2_{ID}_USERNAME, _PASSWORD and _TOKEN.",
+			EnvVars: []string{"LATEST_M2_REPOSITORIES"},
+		},
+		&cli.BoolFlag{
+			Name:  "m2-resolve-snapshots",
+			Usage: "Resolve Maven -SNAPSHOT versions to their latest timestamped artifact",
+		},
+		&cli.StringFlag{
+			Name:    "github-token",
+			Usage:   "Authenticate GitHub API calls with `TOKEN`",
+			EnvVars: []string{"GITHUB_TOKEN", "GH_TOKEN"},
+		},
+		&cli.StringFlag{
+			Name:    "github-token-file",
+			Usage:   "Authenticate GitHub API calls with the token read from `PATH`",
+			EnvVars: []string{"LATEST_GITHUB_TOKEN_FILE"},
+		},
+		&cli.Int64Flag{
+			Name:    "github-app-id",
+			Usage:   "Authenticate GitHub API calls as an installation of the GitHub App `ID`",
+			EnvVars: []string{"LATEST_GITHUB_APP_ID"},
+		},
+		&cli.Int64Flag{
+			Name:    "github-app-installation-id",
+			Usage:   "`ID` of the GitHub App installation",
+			EnvVars: []string{"LATEST_GITHUB_APP_INSTALLATION_ID"},
+		},
+		&cli.StringFlag{
+			Name:    "github-app-private-key",
+			Usage:   "Read the GitHub App private key from `PATH`",
+			EnvVars: []string{"LATEST_GITHUB_APP_PRIVATE_KEY"},
+		},
+		&cli.IntFlag{
+			Name:  "github-max-pages",
+			Usage: "Read at most `N` pages of 100 releases or tags from the GitHub API",
+			Value: 10,
+		},
+		&cli.StringSliceFlag{
+			Name:    "github-enterprise-host",
+			Usage:   "Recognize GitHub Enterprise Server `HOST[=APIBASE]` in queries. The API base defaults to https://HOST/api/v3, and the token is read from $LATEST_GITHUB_TOKEN_{HOST}, e.g. $LATEST_GITHUB_TOKEN_GHE_CORP_EXAMPLE.",
+			EnvVars: []string{"LATEST_GITHUB_ENTERPRISE_HOSTS"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "provider-exec",
+			Usage:   "Fetch the releases of softwareIds starting with \"PREFIX:\" by running `PREFIX=COMMAND`. The command reads {\"software_id\": ...} from stdin and writes {\"releases\": [...]} to stdout.",
+			EnvVars: []string{"LATEST_PROVIDER_EXEC"},
+		},
+		&cli.DurationFlag{
+			Name:    "http-timeout",
+			Usage:   "Time out HTTP requests after `DURATION`",
+			Value:   10 * time.Second,
+			EnvVars: []string{"LATEST_HTTP_TIMEOUT"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "http-host-timeout",
+			Usage:   "Time out HTTP requests to `HOST=DURATION` instead of --http-timeout",
+			EnvVars: []string{"LATEST_HTTP_HOST_TIMEOUTS"},
+		},
+		&cli.IntFlag{
+			Name:    "http-max-retries",
+			Usage:   "Retry HTTP requests up to `N` times on network errors, 5xx and 429 responses",
+			Value:   3,
+			EnvVars: []string{"LATEST_HTTP_MAX_RETRIES"},
+		},
+		&cli.StringFlag{
+			Name:    "http-ca-file",
+			Usage:   "Trust the CA certificates in the PEM bundle at `PATH` in addition to the system ones",
+			EnvVars: []string{"LATEST_HTTP_CA_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "http-client-cert",
+			Usage:   "Present the client certificate in the PEM file at `PATH`",
+			EnvVars: []string{"LATEST_HTTP_CLIENT_CERT"},
+		},
+		&cli.StringFlag{
+			Name:    "http-client-key",
+			Usage:   "Read the private key of --http-client-cert from `PATH`. Defaults to the certificate file.",
+			EnvVars: []string{"LATEST_HTTP_CLIENT_KEY"},
+		},
+		&cli.DurationFlag{
+			Name:    "cache-lifetime",
+			Usage:   "Cache fetched releases for `DURATION` in the server",
+			Value:   fetch.EntryLifetime,
+			EnvVars: []string{"LATEST_CACHE_LIFETIME"},
+		},
+		&cli.DurationFlag{
+			Name:    "cache-negative-lifetime",
+			Usage:   "Cache fetch errors for `DURATION` in the server",
+			Value:   fetch.NegativeLifetime,
+			EnvVars: []string{"LATEST_CACHE_NEGATIVE_LIFETIME"},
+		},
+		&cli.DurationFlag{
+			Name:    "cache-stale-lifetime",
+			Usage:   "Serve releases up to `DURATION` past --cache-lifetime while refetching them in the background, or if refetching fails",
+			Value:   fetch.StaleLifetime,
+			EnvVars: []string{"LATEST_CACHE_STALE_LIFETIME"},
+		},
+		&cli.IntFlag{
+			Name:    "cache-max-entries",
+			Usage:   "Cache the releases of at most `N` softwareIds in the server, evicting the least recently used ones",
+			Value:   fetch.MaxEntries,
+			EnvVars: []string{"LATEST_CACHE_MAX_ENTRIES"},
+		},
+		&cli.BoolFlag{
+			Name:    "disk-cache",
+			Usage:   "Cache fetched releases on disk for --disk-cache-ttl (1h by default), so that runs within it reuse them instead of fetching again. On by default except for serve, which caches in memory and on disk only if set explicitly. Entries fetched with other credentials or repositories are not reused. Disable with --disk-cache=false, or drop the entries with \"latest cache clear\"",
+			Value:   true,
+			EnvVars: []string{"LATEST_DISK_CACHE"},
+		},
+		&cli.StringFlag{
+			Name:    "disk-cache-dir",
+			Usage:   "Store the disk cache in `DIR`",
+			Value:   fetch.DefaultDiskCacheDir(),
+			EnvVars: []string{"LATEST_DISK_CACHE_DIR"},
+		},
+		&cli.DurationFlag{
+			Name:    "disk-cache-ttl",
+			Usage:   "Refetch releases cached on disk for longer than `DURATION`",
+			Value:   time.Hour,
+			EnvVars: []string{"LATEST_DISK_CACHE_TTL"},
+		},
+		&cli.BoolFlag{
+			Name:    "offline",
+			Usage:   "Refuse network access. Releases are fetched only from --snapshot, and from the providers not using HTTP.",
+			EnvVars: []string{"LATEST_OFFLINE"},
+		},
+		&cli.StringFlag{
+			Name:    "snapshot",
+			Usage:   "Fetch releases from the bundle at `PATH` created by \"latest snapshot create\". SoftwareIds not in it are fetched as usual unless --offline.",
+			EnvVars: []string{"LATEST_SNAPSHOT"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "alias",
+			Usage:   "Define `NAME=QUERY` so that NAME can be queried in place of QUERY, e.g. terraform=github.com/hashicorp/terraform@1:assetFilter=linux",
+			EnvVars: []string{"LATEST_ALIASES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "providers-file",
+			Usage:   "Read declarative provider definitions from the YAML file at `PATH`",
+			EnvVars: []string{"LATEST_PROVIDERS_FILE"},
+		},
 	}
 	BeforeImpl := func(c *cli.Context) error {
 		var logger *zap.Logger
@@ -68,6 +237,88 @@ func New() *cli.App {
 
 		zap.ReplaceGlobals(logger)
 
+		cfg, path, err := config.Load(c.String("config"))
+		if err != nil {
+			return err
+		}
+		if path != "" {
+			zap.S().Debugf("Read config from %s", path)
+		}
+		if err := applyConfig(c, cfg); err != nil {
+			return err
+		}
+		config.Loaded = cfg
+
+		for _, spec := range c.StringSlice("alias") {
+			ss := strings.SplitN(spec, "=", 2)
+			if len(ss) != 2 || ss[0] == "" {
+				return fmt.Errorf("Failed to parse alias %q: expected NAME=QUERY", spec)
+			}
+			parser.Aliases[ss[0]] = ss[1]
+		}
+
+		if err := fetch.ConfigureHTTP(fetch.HTTPConfig{
+			Timeout:        c.Duration("http-timeout"),
+			HostTimeouts:   c.StringSlice("http-host-timeout"),
+			MaxRetries:     c.Int("http-max-retries"),
+			CAFile:         c.String("http-ca-file"),
+			ClientCertFile: c.String("http-client-cert"),
+			ClientKeyFile:  c.String("http-client-key"),
+		}); err != nil {
+			return err
+		}
+		fetch.EntryLifetime = c.Duration("cache-lifetime")
+		fetch.NegativeLifetime = c.Duration("cache-negative-lifetime")
+		fetch.StaleLifetime = c.Duration("cache-stale-lifetime")
+		fetch.MaxEntries = c.Int("cache-max-entries")
+		diskCache := c.Bool("disk-cache")
+		if !c.IsSet("disk-cache") && c.String("disk-cache-dir") == "" {
+			// Enabled by default only if there is a home to cache in.
+			diskCache = false
+		}
+		if err := fetch.ConfigureDiskCache(fetch.DiskCacheConfig{
+			Enabled: diskCache,
+			Dir:     c.String("disk-cache-dir"),
+			TTL:     c.Duration("disk-cache-ttl"),
+		}); err != nil {
+			return err
+		}
+
+		if err := fetch.ConfigureOffline(fetch.OfflineConfig{
+			Offline:      c.Bool("offline"),
+			SnapshotPath: c.String("snapshot"),
+		}); err != nil {
+			return err
+		}
+
+		if err := fetch.ConfigureMaven(fetch.MavenConfig{
+			SettingsPath:     c.String("m2-settings"),
+			Repositories:     c.String("m2-repositories"),
+			ResolveSnapshots: c.Bool("m2-resolve-snapshots"),
+		}); err != nil {
+			return err
+		}
+		if err := fetch.ConfigureGitHub(fetch.GitHubConfig{
+			Token:             c.String("github-token"),
+			TokenFile:         c.String("github-token-file"),
+			AppId:             c.Int64("github-app-id"),
+			AppInstallationId: c.Int64("github-app-installation-id"),
+			AppPrivateKeyFile: c.String("github-app-private-key"),
+			MaxPages:          c.Int("github-max-pages"),
+			EnterpriseHosts:   c.StringSlice("github-enterprise-host"),
+		}); err != nil {
+			return err
+		}
+		if err := fetch.ConfigureExecProviders(c.StringSlice("provider-exec")); err != nil {
+			return err
+		}
+		if err := fetch.RegisterDeclarativeProviders(cfg.Providers); err != nil {
+			return err
+		}
+		if err := fetch.ConfigureDeclarativeProviders(c.StringSlice("providers-file")); err != nil {
+			return err
+		}
+
 		return nil
 	}
 	app.Before = func(c *cli.Context) error {
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/IPA-CyberLab/latest/pkg/config"
+)
+
+// applyConfig sets the flags not specified on the command line, nor by the
+// environment variables, to the values in cfg.
+func applyConfig(c *cli.Context, cfg *config.Config) error {
+	values := map[string][]string{}
+	setString := func(name, v string) {
+		if v != "" {
+			values[name] = []string{v}
+		}
+	}
+	setInt := func(name string, v int64) {
+		if v != 0 {
+			values[name] = []string{strconv.FormatInt(v, 10)}
+		}
+	}
+
+	setString("github-token", cfg.GitHub.Token)
+	setString("github-token-file", cfg.GitHub.TokenFile)
+	setInt("github-app-id", cfg.GitHub.AppId)
+	setInt("github-app-installation-id", cfg.GitHub.AppInstallationId)
+	setString("github-app-private-key", cfg.GitHub.AppPrivateKey)
+	setInt("github-max-pages", int64(cfg.GitHub.MaxPages))
+	values["github-enterprise-host"] = cfg.GitHub.EnterpriseHosts
+
+	setString("m2-settings", cfg.Maven.Settings)
+	setString("m2-repositories", strings.Join(cfg.Maven.Repositories, ","))
+	if cfg.Maven.ResolveSnapshots {
+		values["m2-resolve-snapshots"] = []string{"true"}
+	}
+
+	if cfg.HTTP.Timeout != 0 {
+		values["http-timeout"] = []string{cfg.HTTP.Timeout.String()}
+	}
+	values["http-host-timeout"] = cfg.HostTimeoutSpecs()
+	if cfg.HTTP.MaxRetries != nil {
+		values["http-max-retries"] = []string{strconv.Itoa(*cfg.HTTP.MaxRetries)}
+	}
+	setString("http-ca-file", cfg.HTTP.CAFile)
+	setString("http-client-cert", cfg.HTTP.ClientCert)
+	setString("http-client-key", cfg.HTTP.ClientKey)
+	if cfg.Cache.EntryLifetime != 0 {
+		values["cache-lifetime"] = []string{cfg.Cache.EntryLifetime.String()}
+	}
+	if cfg.Cache.NegativeLifetime != 0 {
+		values["cache-negative-lifetime"] = []string{cfg.Cache.NegativeLifetime.String()}
+	}
+	if cfg.Cache.StaleLifetime != 0 {
+		values["cache-stale-lifetime"] = []string{cfg.Cache.StaleLifetime.String()}
+	}
+	setInt("cache-max-entries", int64(cfg.Cache.MaxEntries))
+	if cfg.Cache.Disk != nil {
+		values["disk-cache"] = []string{strconv.FormatBool(*cfg.Cache.Disk)}
+	}
+	setString("disk-cache-dir", cfg.Cache.DiskDir)
+	if cfg.Cache.DiskTTL != 0 {
+		values["disk-cache-ttl"] = []string{cfg.Cache.DiskTTL.String()}
+	}
+	if cfg.Offline {
+		values["offline"] = []string{"true"}
+	}
+	setString("snapshot", cfg.Snapshot)
+	values["provider-exec"] = cfg.ExecProviderSpecs()
+	values["alias"] = cfg.AliasSpecs()
+
+	for name, vs := range values {
+		if c.IsSet(name) {
+			continue
+		}
+		for _, v := range vs {
+			if err := c.Set(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
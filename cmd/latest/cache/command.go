@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+)
+
+var lsCommand = &cli.Command{
+	Name:  "ls",
+	Usage: "List the entries in the disk cache",
+	Action: func(c *cli.Context) error {
+		d := fetch.OpenDiskCache()
+		es, err := d.Entries()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, e := range es {
+			if e.SoftwareId == "" {
+				fmt.Fprintf(w, "%s\t-\t-\t%d\tbroken\n", e.Path, e.Size)
+				continue
+			}
+			status := ""
+			if d.IsExpired(e) {
+				status = "expired"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", e.SoftwareId, e.FetchedAt.UTC().Format(time.RFC3339), len(e.Releases), e.Size, status)
+		}
+		return w.Flush()
+	},
+}
+
+var clearCommand = &cli.Command{
+	Name:  "clear",
+	Usage: "Remove all the entries in the disk cache",
+	Action: func(c *cli.Context) error {
+		d := fetch.OpenDiskCache()
+		n, err := d.Clear()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d entries from %s\n", n, d.Dir)
+		return nil
+	},
+}
+
+var pruneCommand = &cli.Command{
+	Name:  "prune",
+	Usage: "Remove the expired and broken entries in the disk cache",
+	Action: func(c *cli.Context) error {
+		d := fetch.OpenDiskCache()
+		n, err := d.Prune()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d entries from %s\n", n, d.Dir)
+		return nil
+	},
+}
+
+var Command = &cli.Command{
+	Name:        "cache",
+	Usage:       "Inspect the disk cache enabled by --disk-cache",
+	Subcommands: []*cli.Command{lsCommand, clearCommand, pruneCommand},
+}
@@ -0,0 +1,75 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+var Command = &cli.Command{
+	Name:      "changelog",
+	Usage:     "Print the release notes of the releases since the specified version",
+	ArgsUsage: "QUERY",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "The `VERSION` currently in use. Notes of the releases newer than it, up to the one matching the query, are printed.",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		q, err := parser.Parse(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		from := releases.Release{OriginalName: c.String("from")}
+		if q.Scheme == nil {
+			// Versions in a scheme are parsed by Changelog.
+			from.Version, err = parser.ParseVersion(from.OriginalName)
+			if err != nil {
+				return err
+			}
+			from.Components = parser.ParseComponents(from.OriginalName)
+		}
+
+		fetcher := fetch.NewFetcher()
+		rs, err := q.Changelog(c.Context, fetcher, from)
+		if err != nil {
+			return err
+		}
+		if len(rs) == 0 {
+			return fmt.Errorf("No release newer than %s.", from.OriginalName)
+		}
+
+		for i, r := range rs {
+			if i > 0 {
+				fmt.Printf("\n")
+			}
+
+			header := r.OriginalName
+			if !r.PublishedAt.IsZero() {
+				header += fmt.Sprintf(" (%s)", r.PublishedAt.UTC().Format("2006-01-02"))
+			}
+			fmt.Printf("## %s\n\n", header)
+
+			switch {
+			case strings.TrimSpace(r.Notes) != "":
+				fmt.Printf("%s\n", strings.TrimSpace(r.Notes))
+				if r.ChangelogURL != "" {
+					fmt.Printf("\n%s\n", r.ChangelogURL)
+				}
+			case r.ChangelogURL != "":
+				fmt.Printf("See %s\n", r.ChangelogURL)
+			default:
+				fmt.Printf("No release notes available.\n")
+			}
+		}
+		return nil
+	},
+}
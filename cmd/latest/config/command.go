@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/IPA-CyberLab/latest/pkg/config"
+)
+
+var validateCommand = &cli.Command{
+	Name:      "validate",
+	Usage:     "Check the configuration file for errors",
+	ArgsUsage: "[PATH]",
+	Action: func(c *cli.Context) error {
+		path := c.Args().First()
+		if path == "" {
+			path = c.String("config")
+		}
+
+		_, path, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return errors.New("No config file found.")
+		}
+		fmt.Printf("%s: OK\n", path)
+		return nil
+	},
+}
+
+var pathCommand = &cli.Command{
+	Name:  "path",
+	Usage: "Print the paths searched for the configuration file",
+	Action: func(c *cli.Context) error {
+		if path := c.String("config"); path != "" {
+			fmt.Printf("%s\n", path)
+			return nil
+		}
+		for _, path := range config.SearchPaths() {
+			fmt.Printf("%s\n", path)
+		}
+		return nil
+	},
+}
+
+var Command = &cli.Command{
+	Name:        "config",
+	Usage:       "Inspect the configuration file",
+	Subcommands: []*cli.Command{validateCommand, pathCommand},
+}
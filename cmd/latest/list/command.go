@@ -2,6 +2,9 @@ package list
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
@@ -17,6 +20,11 @@ var Command = &cli.Command{
 			Name:  "semver",
 			Usage: "display parsed semver instead of the original version name.",
 		},
+		&cli.BoolFlag{
+			Name:    "long",
+			Aliases: []string{"l"},
+			Usage:   "display the parsed semver, publication date and prerelease status along with the original version name.",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		q, err := parser.Parse(c.Args().First())
@@ -24,11 +32,30 @@ var Command = &cli.Command{
 			return err
 		}
 
-		fetcher := fetch.Direct{}
+		fetcher := fetch.NewFetcher()
 		rs, err := q.Execute(c.Context, fetcher)
 		if err != nil {
 			return err
 		}
+		if q.Scheme == nil {
+			rs = rs.Dedup()
+		}
+
+		if c.Bool("long") {
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			for _, r := range rs {
+				publishedAt := "-"
+				if !r.PublishedAt.IsZero() {
+					publishedAt = r.PublishedAt.UTC().Format(time.RFC3339)
+				}
+				prerelease := ""
+				if r.Prerelease {
+					prerelease = "prerelease"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.OriginalName, r.Version, publishedAt, prerelease)
+			}
+			return w.Flush()
+		}
 
 		if c.Bool("semver") {
 			for _, r := range rs {
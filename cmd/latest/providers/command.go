@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+)
+
+func capabilitiesOf(cs fetch.Capabilities) string {
+	var ss []string
+	if cs.Assets {
+		ss = append(ss, "assets")
+	}
+	if cs.Checksums {
+		ss = append(ss, "checksums")
+	}
+	if cs.Signatures {
+		ss = append(ss, "signatures")
+	}
+	if cs.Platforms {
+		ss = append(ss, "platforms")
+	}
+	if cs.PublishedAt {
+		ss = append(ss, "published_at")
+	}
+	if cs.Notes {
+		ss = append(ss, "notes")
+	}
+	if cs.Changelog {
+		ss = append(ss, "changelog")
+	}
+	if len(ss) == 0 {
+		return "-"
+	}
+	return strings.Join(ss, ",")
+}
+
+var Command = &cli.Command{
+	Name:  "providers",
+	Usage: "List the registered release providers in the order they are tried",
+	Action: func(c *cli.Context) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, p := range fetch.DefaultRegistry.Providers() {
+			prefixes := "-"
+			if len(p.Prefixes()) > 0 {
+				prefixes = strings.Join(p.Prefixes(), ":,") + ":"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name(), prefixes, capabilitiesOf(p.Capabilities()))
+		}
+		return w.Flush()
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -46,6 +47,10 @@ var Command = &cli.Command{
 			Aliases: []string{"f"},
 			Usage:   "Apply filter to list of asset URLs.",
 		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "Download the selected assets and verify them against their published checksums.",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		assetQ := AssetQueryNone
@@ -79,7 +84,7 @@ var Command = &cli.Command{
 			return err
 		}
 
-		fetcher := fetch.Direct{}
+		fetcher := fetch.NewFetcher()
 		rs, err := q.Execute(c.Context, fetcher)
 		if err != nil {
 			return err
@@ -91,7 +96,7 @@ var Command = &cli.Command{
 
 		filters := c.StringSlice("assetFilter")
 		for _, f := range filters {
-			r.FilterAssets(f)
+			r.FilterAssets(strings.ToLower(f))
 		}
 
 		if assetQ == AssetQueryNone && outputType != OutputTypeLine {
@@ -102,18 +107,37 @@ var Command = &cli.Command{
 			break
 		case AssetQueryGuess:
 			r.PickAsset()
-			if len(r.AssetURLs) == 0 {
+			if len(r.Assets) == 0 {
 				return fmt.Errorf("Failed to find asset.")
 			}
-			if len(r.AssetURLs) > 1 {
-				fmt.Fprintf(os.Stderr, "Too many matches: %v", r.AssetURLs)
+			if len(r.Assets) > 1 {
+				fmt.Fprintf(os.Stderr, "Too many matches: %v", r.AssetURLs())
+			}
+		}
+
+		if c.Bool("verify") {
+			// Verify the asset which would be guessed, without printing it
+			// in place of the version name.
+			vr := r
+			if assetQ == AssetQueryNone {
+				vr.PickAsset()
+			}
+			if len(vr.Assets) == 0 {
+				return fmt.Errorf("Failed to find asset to verify.")
+			}
+			for _, u := range vr.AssetURLs() {
+				algo, err := fetch.VerifyAsset(c.Context, vr, u)
+				if err != nil {
+					return fmt.Errorf("Failed to verify %s: %w", u, err)
+				}
+				fmt.Fprintf(os.Stderr, "Verified %s (%s)\n", u, algo)
 			}
 		}
 
 		switch outputType {
 		case OutputTypeLine:
 			if assetQ != AssetQueryNone {
-				for _, u := range r.AssetURLs {
+				for _, u := range r.AssetURLs() {
 					fmt.Printf("%s\n", u)
 				}
 				return nil
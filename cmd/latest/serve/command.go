@@ -8,6 +8,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 
+	"github.com/IPA-CyberLab/latest/pkg/config"
 	"github.com/IPA-CyberLab/latest/pkg/exporter"
 	"github.com/IPA-CyberLab/latest/pkg/fetch"
 )
@@ -28,15 +29,21 @@ var Command = &cli.Command{
 	Usage: "Launch a http server that serves queries in prometheus format",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
-			Name:  "listen-addr",
-			Usage: "server listen `HOST:ADDR`",
-			Value: ":16480",
+			Name:    "listen-addr",
+			Usage:   "server listen `HOST:ADDR`",
+			Value:   ":16480",
+			EnvVars: []string{"LATEST_LISTEN_ADDR"},
 		},
 	},
 	Action: func(c *cli.Context) error {
 		mux := http.NewServeMux()
 
-		fetcher := fetch.NewCachedFetcher(fetch.Direct{})
+		// Releases are cached in memory by the server. Caching them on disk
+		// as well, into the home of whoever runs it, is left to be asked for.
+		if !c.IsSet("disk-cache") {
+			fetch.DisableDiskCache()
+		}
+		fetcher := fetch.NewCachedFetcher(fetch.NewFetcher())
 		mux.Handle("/probe", exporter.Handler{Fetcher: fetcher})
 
 		prometheus.MustRegister(prometheus.NewBuildInfoCollector())
@@ -58,6 +65,9 @@ var Command = &cli.Command{
 		})
 
 		listenAddr := c.String("listen-addr")
+		if !c.IsSet("listen-addr") && config.Loaded.Server.ListenAddr != "" {
+			listenAddr = config.Loaded.Server.ListenAddr
+		}
 		zap.S().Infof("About to start listening on %s", listenAddr)
 		if err := http.ListenAndServe(listenAddr, mux); err != nil {
 			return err
@@ -0,0 +1,84 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+)
+
+var createCommand = &cli.Command{
+	Name:      "create",
+	Usage:     "Resolve the queries and write the releases of their softwareIds to a bundle for --snapshot",
+	ArgsUsage: "[QUERY...]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "queries",
+			Usage: "Read the queries from `PATH`, one per line. Blank lines and lines starting with \"#\" are ignored.",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Write the bundle to `PATH`",
+			Value:   "latest-snapshot.json",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		queries := c.Args().Slice()
+		if path := c.String("queries"); path != "" {
+			bs, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("Failed to read queries: %w", err)
+			}
+			queries = append(queries, fetch.ParseQueries(bs)...)
+		}
+		if len(queries) == 0 {
+			return errors.New("No query specified.")
+		}
+
+		s, err := fetch.CreateSnapshot(c.Context, fetch.NewFetcher(), queries)
+		if err != nil {
+			return err
+		}
+		if err := s.WriteFile(c.String("output")); err != nil {
+			return err
+		}
+		zap.S().Infof("Wrote releases of %d softwareIds to %s", len(s.Releases), c.String("output"))
+		return nil
+	},
+}
+
+var lsCommand = &cli.Command{
+	Name:      "ls",
+	Usage:     "List the softwareIds in a bundle",
+	ArgsUsage: "PATH",
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return errors.New("Specify the path of the bundle.")
+		}
+		s, err := fetch.ReadSnapshot(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created at %s\n", s.CreatedAt.UTC().Format(time.RFC3339))
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, softwareId := range s.SoftwareIds() {
+			fmt.Fprintf(w, "%s\t%d\n", softwareId, len(s.Releases[softwareId]))
+		}
+		return w.Flush()
+	},
+}
+
+var Command = &cli.Command{
+	Name:        "snapshot",
+	Usage:       "Create and inspect bundles of release metadata for offline use",
+	Subcommands: []*cli.Command{createCommand, lsCommand},
+}
@@ -0,0 +1,272 @@
+// Package config reads latest.yaml, the configuration file shared by the CLI
+// and the server.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+)
+
+const FileName = "latest.yaml"
+
+type GitHub struct {
+	Token             string `yaml:"token,omitempty"`
+	TokenFile         string `yaml:"token_file,omitempty"`
+	AppId             int64  `yaml:"app_id,omitempty"`
+	AppInstallationId int64  `yaml:"app_installation_id,omitempty"`
+	AppPrivateKey     string `yaml:"app_private_key,omitempty"`
+	MaxPages          int    `yaml:"max_pages,omitempty"`
+	// EnterpriseHosts are "HOST" or "HOST=APIBASE".
+	EnterpriseHosts []string `yaml:"enterprise_hosts,omitempty"`
+}
+
+type Maven struct {
+	Settings string `yaml:"settings,omitempty"`
+	// Repositories are "ID=URL" pairs.
+	Repositories     []string `yaml:"repositories,omitempty"`
+	ResolveSnapshots bool     `yaml:"resolve_snapshots,omitempty"`
+}
+
+type HTTP struct {
+	Timeout      time.Duration            `yaml:"timeout,omitempty"`
+	HostTimeouts map[string]time.Duration `yaml:"host_timeouts,omitempty"`
+	// MaxRetries is a pointer to tell 0 from unset.
+	MaxRetries *int   `yaml:"max_retries,omitempty"`
+	CAFile     string `yaml:"ca_file,omitempty"`
+	ClientCert string `yaml:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key,omitempty"`
+}
+
+type Cache struct {
+	EntryLifetime    time.Duration `yaml:"entry_lifetime,omitempty"`
+	NegativeLifetime time.Duration `yaml:"negative_lifetime,omitempty"`
+	StaleLifetime    time.Duration `yaml:"stale_lifetime,omitempty"`
+	MaxEntries       int           `yaml:"max_entries,omitempty"`
+
+	// Disk enables the on-disk cache shared between runs, in DiskDir for
+	// DiskTTL. It is a pointer to tell false from unset, which enables it
+	// for the commands other than serve.
+	Disk    *bool         `yaml:"disk,omitempty"`
+	DiskDir string        `yaml:"disk_dir,omitempty"`
+	DiskTTL time.Duration `yaml:"disk_ttl,omitempty"`
+}
+
+type Server struct {
+	ListenAddr string `yaml:"listen_addr,omitempty"`
+}
+
+type Config struct {
+	GitHub GitHub `yaml:"github,omitempty"`
+	Maven  Maven  `yaml:"maven,omitempty"`
+	HTTP   HTTP   `yaml:"http,omitempty"`
+	Cache  Cache  `yaml:"cache,omitempty"`
+	Server Server `yaml:"server,omitempty"`
+
+	// Offline refuses network access, and Snapshot is a bundle created by
+	// "latest snapshot create" to fetch from.
+	Offline  bool   `yaml:"offline,omitempty"`
+	Snapshot string `yaml:"snapshot,omitempty"`
+
+	// Aliases map a name to the query it stands for, e.g.
+	// "terraform: github.com/hashicorp/terraform@1:assetFilter=linux".
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// Providers are declarative providers, and ExecProviders map a prefix to
+	// the command line of an external-command provider.
+	Providers     []fetch.DeclarativeSpec `yaml:"providers,omitempty"`
+	ExecProviders map[string]string       `yaml:"exec_providers,omitempty"`
+}
+
+// Loaded is the configuration read on startup. Empty if no file was found.
+var Loaded = &Config{}
+
+// SearchPaths returns the paths to look for latest.yaml in the order of
+// precedence, following the XDG Base Directory Specification.
+func SearchPaths() []string {
+	var paths []string
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "latest", FileName))
+	}
+
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if dir != "" {
+			paths = append(paths, filepath.Join(dir, "latest", FileName))
+		}
+	}
+	return paths
+}
+
+// Find returns the path of the first latest.yaml found in SearchPaths, or ""
+// if none.
+func Find() string {
+	for _, path := range SearchPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Parse reads the configuration in YAML. Unknown keys are errors, so that
+// typos don't go unnoticed.
+func Parse(bs []byte) (*Config, error) {
+	cfg := &Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load reads the configuration at path, or the one found by Find if path is
+// empty. It returns an empty Config if path is empty and none is found.
+func Load(path string) (*Config, string, error) {
+	if path == "" {
+		path = Find()
+		if path == "" {
+			return &Config{}, "", nil
+		}
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("Failed to read config: %w", err)
+	}
+	cfg, err := Parse(bs)
+	if err != nil {
+		return nil, path, fmt.Errorf("Failed to parse config %q: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, path, fmt.Errorf("Invalid config %q: %w", path, err)
+	}
+	return cfg, path, nil
+}
+
+// HostTimeoutSpecs returns HTTP.HostTimeouts as "HOST=DURATION" specs,
+// sorted by the host.
+func (cfg *Config) HostTimeoutSpecs() []string {
+	specs := make([]string, 0, len(cfg.HTTP.HostTimeouts))
+	for host, d := range cfg.HTTP.HostTimeouts {
+		specs = append(specs, host+"="+d.String())
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+// ExecProviderSpecs returns ExecProviders as "PREFIX=COMMAND" specs, sorted
+// by the prefix.
+func (cfg *Config) ExecProviderSpecs() []string {
+	specs := make([]string, 0, len(cfg.ExecProviders))
+	for prefix, command := range cfg.ExecProviders {
+		specs = append(specs, prefix+"="+command)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+var reAliasName = regexp.MustCompile(`^[^@<>=:]+$`)
+
+// AliasSpecs returns Aliases as "NAME=QUERY" specs, sorted by the name.
+func (cfg *Config) AliasSpecs() []string {
+	specs := make([]string, 0, len(cfg.Aliases))
+	for name, q := range cfg.Aliases {
+		specs = append(specs, name+"="+q)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+func (cfg *Config) Validate() error {
+	var errs []string
+
+	if cfg.HTTP.Timeout < 0 {
+		errs = append(errs, "http.timeout must not be negative")
+	}
+	for host, d := range cfg.HTTP.HostTimeouts {
+		if d <= 0 {
+			errs = append(errs, fmt.Sprintf("http.host_timeouts: timeout of %q must be positive", host))
+		}
+	}
+	if cfg.HTTP.MaxRetries != nil && *cfg.HTTP.MaxRetries < 0 {
+		errs = append(errs, "http.max_retries must not be negative")
+	}
+	if cfg.HTTP.ClientKey != "" && cfg.HTTP.ClientCert == "" {
+		errs = append(errs, "http.client_key requires http.client_cert")
+	}
+	if cfg.Cache.EntryLifetime < 0 {
+		errs = append(errs, "cache.entry_lifetime must not be negative")
+	}
+	if cfg.Cache.NegativeLifetime < 0 {
+		errs = append(errs, "cache.negative_lifetime must not be negative")
+	}
+	if cfg.Cache.StaleLifetime < 0 {
+		errs = append(errs, "cache.stale_lifetime must not be negative")
+	}
+	if cfg.Cache.MaxEntries < 0 {
+		errs = append(errs, "cache.max_entries must not be negative")
+	}
+	if cfg.Cache.DiskTTL < 0 {
+		errs = append(errs, "cache.disk_ttl must not be negative")
+	}
+	if cfg.GitHub.MaxPages < 0 {
+		errs = append(errs, "github.max_pages must not be negative")
+	}
+	for _, spec := range cfg.Maven.Repositories {
+		if !strings.Contains(spec, "=") {
+			errs = append(errs, fmt.Sprintf("maven.repositories: expected ID=URL, got %q", spec))
+		}
+	}
+	for _, spec := range cfg.AliasSpecs() {
+		ss := strings.SplitN(spec, "=", 2)
+		name, q := ss[0], ss[1]
+		if !reAliasName.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("aliases: invalid name %q", name))
+			continue
+		}
+		if _, err := parser.Parse(q); err != nil {
+			errs = append(errs, fmt.Sprintf("aliases: %s: %v", name, err))
+		}
+	}
+	for _, spec := range cfg.Providers {
+		if _, err := fetch.NewDeclarativeProvider(spec); err != nil {
+			errs = append(errs, fmt.Sprintf("providers: %v", err))
+		}
+	}
+	for _, spec := range cfg.ExecProviderSpecs() {
+		if _, err := fetch.ParseExecProviderSpec(spec); err != nil {
+			errs = append(errs, fmt.Sprintf("exec_providers: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/config"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+github:
+  token_file: /run/secrets/github
+  enterprise_hosts: [ghe.corp.example]
+http:
+  timeout: 30s
+cache:
+  entry_lifetime: 1h
+  disk: false
+aliases:
+  terraform: github.com/hashicorp/terraform@1:assetFilter=linux
+exec_providers:
+  vendor: /usr/local/bin/latest-vendor --verbose
+  intra: latest-intra
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHub.TokenFile != "/run/secrets/github" || cfg.HTTP.Timeout != 30*time.Second || cfg.Cache.EntryLifetime != time.Hour {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+	if cfg.Cache.Disk == nil || *cfg.Cache.Disk {
+		t.Errorf("Expected the disk cache to be disabled explicitly, got %v", cfg.Cache.Disk)
+	}
+	expected := []string{"intra=latest-intra", "vendor=/usr/local/bin/latest-vendor --verbose"}
+	if diff := cmp.Diff(expected, cfg.ExecProviderSpecs()); diff != "" {
+		t.Errorf("Unexpected exec provider specs (-want +got):\n%s", diff)
+	}
+
+	if cfg, err := config.Parse(nil); err != nil || cfg == nil {
+		t.Errorf("Expected an empty config to parse, got %v", err)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, s := range []string{
+		"http:\n  timout: 30s\n",
+		"http:\n  timeout: soon\n",
+		"cache:\n  entry_lifetime: -1m\n",
+		"maven:\n  repositories: [central]\n",
+		"providers:\n- prefix: acme\n",
+		"exec_providers:\n  vendor: \"\"\n",
+		"aliases:\n  \"tf:x\": github.com/hashicorp/terraform\n",
+		"aliases:\n  tf: github.com/hashicorp/terraform@1.x\n",
+	} {
+		cfg, err := config.Parse([]byte(s))
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err == nil {
+			t.Errorf("Expected error for config %q", s)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "latest-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+	os.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "etc"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	defer os.Unsetenv("XDG_CONFIG_DIRS")
+
+	if _, path, err := config.Load(""); err != nil || path != "" {
+		t.Errorf("Expected no config found, got %q, %v", path, err)
+	}
+
+	etcPath := filepath.Join(dir, "etc", "latest", config.FileName)
+	if err := os.MkdirAll(filepath.Dir(etcPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(etcPath, []byte("server:\n  listen_addr: :8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, path, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != etcPath || cfg.Server.ListenAddr != ":8080" {
+		t.Errorf("Unexpected config %+v at %q", cfg, path)
+	}
+
+	if _, _, err := config.Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("Expected error for an explicitly specified missing config")
+	}
+}
@@ -23,15 +23,36 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		prometheus.GaugeOpts{Namespace: "latest", Name: "release", Help: "Information about a software release."},
 		[]string{"query", "software", "version", "semver", "prerelease"})
 	reg.MustRegister(releaseVec)
+	publishedVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Namespace: "latest", Name: "release_published_timestamp_seconds", Help: "The time the software release was published, in unix epoch seconds. Absent if unknown."},
+		[]string{"query", "software", "version"})
+	reg.MustRegister(publishedVec)
 
 	vals := req.URL.Query()
 
+	type parsedQuery struct {
+		qval string
+		q    *query.Query
+	}
+	var qs []parsedQuery
+	var softwareIds []string
 	for _, qval := range vals["q"] {
 		q, err := parser.Parse(qval)
 		if err != nil {
 			l.Infof("Failed to parse %q err: %v\n", q, err)
 			continue
 		}
+		qs = append(qs, parsedQuery{qval: qval, q: q})
+		softwareIds = append(softwareIds, q.SoftwareId)
+	}
+
+	// Let the fetcher batch the queries, e.g. through the GitHub GraphQL API.
+	if pf, ok := h.Fetcher.(query.Prefetcher); ok {
+		pf.Prefetch(req.Context(), softwareIds)
+	}
+
+	for _, pq := range qs {
+		qval, q := pq.qval, pq.q
 
 		rs, err := q.Execute(req.Context(), h.Fetcher)
 		if err != nil {
@@ -50,6 +71,9 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 
 		releaseVec.WithLabelValues(qval, q.SoftwareId, r.OriginalName, r.Version.String(), prereleaseInt).Set(1)
+		if !r.PublishedAt.IsZero() {
+			publishedVec.WithLabelValues(qval, q.SoftwareId, r.OriginalName).Set(float64(r.PublishedAt.Unix()))
+		}
 	}
 
 	handler := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
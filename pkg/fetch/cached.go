@@ -1,7 +1,9 @@
 package fetch
 
 import (
+	"container/list"
 	"context"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,39 +17,90 @@ type Backend interface {
 	Fetch(ctx context.Context, softwareId string) (rs releases.Releases, err error)
 }
 
-type cachedFetcher struct {
-	backend Backend
-	reqC    chan request
+// BatchBackend is a Backend which can fetch many softwareIds at once.
+type BatchBackend interface {
+	Backend
+	FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error)
 }
 
-func NewCachedFetcher(backend Backend) *cachedFetcher {
-	c := &cachedFetcher{
-		backend: backend,
-		reqC:    make(chan request),
+// fetchMany fetches softwareIds from backend, all at once if it is a
+// BatchBackend.
+func fetchMany(ctx context.Context, backend Backend, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	if bb, ok := backend.(BatchBackend); ok {
+		return bb.FetchMany(ctx, softwareIds)
 	}
-	go c.cacheMain()
 
-	return c
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+	for _, softwareId := range softwareIds {
+		rs, err := backend.Fetch(ctx, softwareId)
+		if err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		rss[softwareId] = rs
+	}
+	return rss, errs
 }
 
 var NowImpl func() time.Time = time.Now
-var EntryLifetime = 30 * time.Minute
 
-type response struct {
-	rs  []releases.Release
-	err error
+var (
+	// EntryLifetime is how long fetched releases are served without
+	// refetching.
+	EntryLifetime = 30 * time.Minute
+	// NegativeLifetime is how long fetch errors are served without
+	// refetching, and how long to wait before retrying a failed refresh.
+	NegativeLifetime = time.Minute
+	// StaleLifetime is how long releases are served past EntryLifetime,
+	// while being refetched in the background or if refetching fails.
+	StaleLifetime = 24 * time.Hour
+	// MaxEntries bounds the number of softwareIds cached. The least
+	// recently used ones are evicted first.
+	MaxEntries = 10000
+)
+
+type entry struct {
+	softwareId  string
+	fetchedTime time.Time
+	rs          releases.Releases
+	err         error
+
+	// refreshFailedTime is when refetching the stale entry failed last.
+	refreshFailedTime time.Time
 }
 
-type request struct {
-	softwareId string
-	resC       chan<- response
+// call is a fetch in flight, shared by the callers of the same softwareId.
+type call struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	doneC  chan struct{}
+	rs     releases.Releases
+	err    error
+
+	// waiters is the number of callers waiting for the call. The fetch is
+	// cancelled once all of them give up, unless it is detached, i.e. a
+	// refresh or a prefetch.
+	waiters  int
+	detached bool
 }
 
-func (c *cachedFetcher) Fetch(ctx context.Context, softwareId string) (rs releases.Releases, err error) {
-	resC := make(chan response)
-	c.reqC <- request{softwareId: softwareId, resC: resC}
-	resp := <-resC
-	return resp.rs, resp.err
+type cachedFetcher struct {
+	backend Backend
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	calls   map[string]*call
+}
+
+func NewCachedFetcher(backend Backend) *cachedFetcher {
+	return &cachedFetcher{
+		backend: backend,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		calls:   make(map[string]*call),
+	}
 }
 
 var hitrateVec = promauto.NewCounterVec(prometheus.CounterOpts{
@@ -57,48 +110,190 @@ var hitrateVec = promauto.NewCounterVec(prometheus.CounterOpts{
 	Help:      "Total number of fetches through cached_fetcher by its softwareId and cache hit.",
 }, []string{"software", "cache_hit"})
 
-func (c cachedFetcher) cacheMain() {
+// lookup returns the cached entry of softwareId and whether it is fresh, or
+// nil if there is none or it is too old to be served. c.mu must be held.
+func (c *cachedFetcher) lookup(softwareId string, now time.Time) (*entry, bool) {
+	el, ok := c.entries[softwareId]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+
+	age := now.Sub(e.fetchedTime)
+	switch {
+	case e.err != nil && age <= NegativeLifetime:
+		return e, true
+	case e.err == nil && age <= EntryLifetime:
+		return e, true
+	case e.err == nil && age <= EntryLifetime+StaleLifetime:
+		return e, false
+	}
+	c.lru.Remove(el)
+	delete(c.entries, softwareId)
+	return nil, false
+}
+
+// put caches e, evicting the least recently used entries beyond
+// MaxEntries. c.mu must be held.
+func (c *cachedFetcher) put(e *entry) {
+	if el, ok := c.entries[e.softwareId]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[e.softwareId] = c.lru.PushFront(e)
+	}
+	for MaxEntries > 0 && c.lru.Len() > MaxEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*entry).softwareId)
+	}
+}
+
+// newCall registers a call fetching softwareId, which must not be in flight.
+// c.mu must be held.
+func (c *cachedFetcher) newCall(ctx context.Context, softwareId string, detached bool) *call {
+	ctx, cancel := context.WithCancel(ctx)
+	cl := &call{
+		ctx:      ctx,
+		cancel:   cancel,
+		doneC:    make(chan struct{}),
+		detached: detached,
+	}
+	c.calls[softwareId] = cl
+	return cl
+}
+
+// startCall returns the call fetching softwareId, starting one if none is
+// in flight. c.mu must be held.
+func (c *cachedFetcher) startCall(softwareId string, detached bool) *call {
+	if cl, ok := c.calls[softwareId]; ok {
+		cl.detached = cl.detached || detached
+		return cl
+	}
+
+	// The fetch outlives the callers giving up, if shared with others.
+	cl := c.newCall(context.Background(), softwareId, detached)
+	go func() {
+		rs, err := c.backend.Fetch(cl.ctx, softwareId)
+		c.complete(softwareId, cl, rs, err)
+	}()
+	return cl
+}
+
+// complete caches the result of cl and wakes up its waiters. If the fetch
+// failed, the stale releases are kept and served instead.
+func (c *cachedFetcher) complete(softwareId string, cl *call, rs releases.Releases, err error) {
 	l := zap.S()
 
-	type entry struct {
-		waitC chan struct{}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(cl.doneC)
+	defer cl.cancel()
 
-		fetchedTime time.Time
-		rs          []releases.Release
-		err         error
+	if c.calls[softwareId] == cl {
+		delete(c.calls, softwareId)
 	}
-	entries := make(map[string]*entry)
+	cl.rs, cl.err = rs, err
 
-	for req := range c.reqC {
-		now := NowImpl()
+	if err != nil && cl.ctx.Err() != nil {
+		// Given up by all the callers; the error tells nothing about upstream.
+		return
+	}
 
-		e := entries[req.softwareId]
-		if e != nil && now.Sub(e.fetchedTime) > EntryLifetime {
-			e = nil
+	now := NowImpl()
+	if err != nil {
+		if prev, _ := c.lookup(softwareId, now); prev != nil && prev.err == nil {
+			l.Warnf("Failed to refetch %q, serving the releases fetched at %v: %v", softwareId, prev.fetchedTime, err)
+			prev.refreshFailedTime = now
+			cl.rs, cl.err = prev.rs, nil
+			return
 		}
+	}
+	c.put(&entry{softwareId: softwareId, fetchedTime: now, rs: rs, err: err})
+}
 
-		if e != nil {
-			<-e.waitC
+func (c *cachedFetcher) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	l := zap.S()
 
-			l.Debugf("cache hit: returning entry %q from cache.", req.softwareId)
-			hitrateVec.WithLabelValues(req.softwareId, "hit").Inc()
-			req.resC <- response{rs: e.rs, err: e.err}
-			close(req.resC)
-			continue
+	c.mu.Lock()
+	now := NowImpl()
+	e, fresh := c.lookup(softwareId, now)
+	if e != nil {
+		c.lru.MoveToFront(c.entries[softwareId])
+		if !fresh && now.Sub(e.refreshFailedTime) > NegativeLifetime {
+			l.Debugf("cache stale: %q refetching in the background.", softwareId)
+			c.startCall(softwareId, true)
+		}
+		c.mu.Unlock()
+
+		if fresh {
+			l.Debugf("cache hit: returning entry %q from cache.", softwareId)
+			hitrateVec.WithLabelValues(softwareId, "hit").Inc()
+		} else {
+			hitrateVec.WithLabelValues(softwareId, "stale").Inc()
+		}
+		return e.rs, e.err
+	}
+
+	cl := c.startCall(softwareId, false)
+	cl.waiters++
+	c.mu.Unlock()
+
+	l.Debugf("cache miss: %q waiting for fetch.", softwareId)
+	hitrateVec.WithLabelValues(softwareId, "miss").Inc()
+
+	select {
+	case <-cl.doneC:
+		return cl.rs, cl.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		cl.waiters--
+		if cl.waiters == 0 && !cl.detached {
+			cl.cancel()
+			// Let the next caller start over instead of joining the
+			// cancelled fetch.
+			if c.calls[softwareId] == cl {
+				delete(c.calls, softwareId)
+			}
 		}
-		e = &entry{waitC: make(chan struct{})}
-		entries[req.softwareId] = e
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
 
-		l.Debugf("cache miss: %q new fetch.", req.softwareId)
-		hitrateVec.WithLabelValues(req.softwareId, "miss").Inc()
-		go func() {
-			e.rs, e.err = c.backend.Fetch(context.Background(), req.softwareId)
-			e.fetchedTime = NowImpl()
-			close(e.waitC)
-		}()
+// Prefetch starts fetching the softwareIds which are not cached yet, all at
+// once if the backend is a BatchBackend. It returns without waiting for the
+// fetch to complete; subsequent Fetch calls wait for it instead.
+func (c *cachedFetcher) Prefetch(ctx context.Context, softwareIds []string) {
+	bb, ok := c.backend.(BatchBackend)
+	if !ok {
+		return
+	}
 
-		<-e.waitC
-		req.resC <- response{rs: e.rs, err: e.err}
-		close(req.resC)
+	c.mu.Lock()
+	now := NowImpl()
+	var ids []string
+	calls := make(map[string]*call)
+	for _, softwareId := range softwareIds {
+		if e, fresh := c.lookup(softwareId, now); e != nil && fresh {
+			continue
+		}
+		if _, ok := c.calls[softwareId]; ok {
+			continue
+		}
+		calls[softwareId] = c.newCall(context.Background(), softwareId, true)
+		ids = append(ids, softwareId)
 	}
+	c.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	zap.S().Debugf("prefetch: %d entries new fetch.", len(ids))
+	go func() {
+		rss, errs := bb.FetchMany(context.Background(), ids)
+		for softwareId, cl := range calls {
+			c.complete(softwareId, cl, rss[softwareId], errs[softwareId])
+		}
+	}()
 }
@@ -0,0 +1,194 @@
+package fetch_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// gatedBackend returns version "1.0.<number of fetches>" of a softwareId once
+// its gate, if any, is opened, or err if set.
+type gatedBackend struct {
+	mu      sync.Mutex
+	fetches map[string]int
+	gates   map[string]chan struct{}
+	err     error
+}
+
+func newGatedBackend() *gatedBackend {
+	return &gatedBackend{fetches: map[string]int{}, gates: map[string]chan struct{}{}}
+}
+
+func (b *gatedBackend) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	b.mu.Lock()
+	gate := b.gates[softwareId]
+	b.mu.Unlock()
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fetches[softwareId]++
+	if b.err != nil {
+		return nil, b.err
+	}
+	v := semver.Version{Major: 1, Patch: uint64(b.fetches[softwareId])}
+	return releases.Releases{{OriginalName: v.String(), Version: v}}, nil
+}
+
+func (b *gatedBackend) numFetches(softwareId string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fetches[softwareId]
+}
+
+func (b *gatedBackend) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func versionOf(t *testing.T, f fetch.Backend, softwareId string) string {
+	t.Helper()
+	rs, err := f.Fetch(context.Background(), softwareId)
+	if err != nil {
+		t.Fatalf("Fetch(%q): %v", softwareId, err)
+	}
+	return rs[0].Version.String()
+}
+
+// eventually polls cond, which is satisfied by a background fetch.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out")
+}
+
+func TestCachedFetcherSingleflight(t *testing.T) {
+	b := newGatedBackend()
+	gate := make(chan struct{})
+	b.gates["slow"] = gate
+	c := fetch.NewCachedFetcher(b)
+
+	var wg sync.WaitGroup
+	vs := make([]string, 5)
+	for i := range vs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vs[i] = versionOf(t, c, "slow")
+		}(i)
+	}
+
+	// Not blocked by the fetch of "slow" in flight.
+	if v := versionOf(t, c, "fast"); v != "1.0.1" {
+		t.Errorf("fast: %s", v)
+	}
+
+	close(gate)
+	wg.Wait()
+	for _, v := range vs {
+		if v != "1.0.1" {
+			t.Errorf("slow: %s", v)
+		}
+	}
+	if n := b.numFetches("slow"); n != 1 {
+		t.Errorf("slow fetched %d times", n)
+	}
+}
+
+func TestCachedFetcherCancel(t *testing.T) {
+	b := newGatedBackend()
+	gate := make(chan struct{})
+	b.gates["slow"] = gate
+	c := fetch.NewCachedFetcher(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Fetch(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// The cancelled fetch is neither cached nor joined.
+	close(gate)
+	if v := versionOf(t, c, "slow"); v != "1.0.1" {
+		t.Errorf("slow: %s", v)
+	}
+}
+
+func TestCachedFetcherLifetimes(t *testing.T) {
+	now := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	fetch.NowImpl = func() time.Time { return now }
+	defer func() { fetch.NowImpl = time.Now }()
+
+	b := newGatedBackend()
+	c := fetch.NewCachedFetcher(b)
+
+	b.setErr(errors.New("unavailable"))
+	if _, err := c.Fetch(context.Background(), "foo"); err == nil {
+		t.Fatalf("expected error")
+	}
+	b.setErr(nil)
+	if _, err := c.Fetch(context.Background(), "foo"); err == nil {
+		t.Errorf("error should be cached for NegativeLifetime")
+	}
+	now = now.Add(fetch.NegativeLifetime + time.Second)
+	if v := versionOf(t, c, "foo"); v != "1.0.2" {
+		t.Errorf("after NegativeLifetime: %s", v)
+	}
+
+	now = now.Add(fetch.EntryLifetime + time.Second)
+	if v := versionOf(t, c, "foo"); v != "1.0.2" {
+		t.Errorf("stale entry should be served while refetching: %s", v)
+	}
+	eventually(t, func() bool { return versionOf(t, c, "foo") == "1.0.3" })
+
+	b.setErr(errors.New("unavailable"))
+	now = now.Add(fetch.EntryLifetime + time.Second)
+	if v := versionOf(t, c, "foo"); v != "1.0.3" {
+		t.Errorf("stale entry should be served while refetching: %s", v)
+	}
+	eventually(t, func() bool { return b.numFetches("foo") == 4 })
+	if v := versionOf(t, c, "foo"); v != "1.0.3" {
+		t.Errorf("stale entry should be served on error: %s", v)
+	}
+
+	now = now.Add(fetch.StaleLifetime)
+	if _, err := c.Fetch(context.Background(), "foo"); err == nil {
+		t.Errorf("expected error past StaleLifetime")
+	}
+}
+
+func TestCachedFetcherEviction(t *testing.T) {
+	defer func(n int) { fetch.MaxEntries = n }(fetch.MaxEntries)
+	fetch.MaxEntries = 2
+
+	b := newGatedBackend()
+	c := fetch.NewCachedFetcher(b)
+
+	for _, softwareId := range []string{"a", "b", "a", "c", "a", "b"} {
+		versionOf(t, c, softwareId)
+	}
+	if n := b.numFetches("a"); n != 1 {
+		t.Errorf("a fetched %d times", n)
+	}
+	if n := b.numFetches("b"); n != 2 {
+		t.Errorf("b should be evicted as the least recently used: fetched %d times", n)
+	}
+}
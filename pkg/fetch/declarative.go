@@ -0,0 +1,106 @@
+package fetch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/declarative"
+)
+
+// DeclarativeSpec defines a provider by the URL to fetch and the selectors
+// extracting releases from the response. See declarative.Spec for details.
+type DeclarativeSpec = declarative.Spec
+
+type declarativeProvider struct {
+	*declarative.Provider
+}
+
+func (p declarativeProvider) Name() string       { return p.Spec.Name }
+func (p declarativeProvider) Prefixes() []string { return []string{p.Spec.Prefix} }
+
+func (p declarativeProvider) Capabilities() Capabilities {
+	return Capabilities{
+		Assets:      p.Spec.Assets != "" || len(p.Spec.AssetTemplates) > 0,
+		PublishedAt: p.Spec.Date != "",
+	}
+}
+
+func NewDeclarativeProvider(spec DeclarativeSpec) (Provider, error) {
+	p, err := declarative.New(spec)
+	if err != nil {
+		return nil, err
+	}
+	return declarativeProvider{p}, nil
+}
+
+// ProvidersFile is the format of the files read by LoadDeclarativeProviders:
+//
+//	providers:
+//	- prefix: acme
+//	  url: https://downloads.acme.example/{{.Name}}/releases.json
+//	  releases: $.releases[*]
+//	  version: .version
+//	  date: .date
+//	  date_layout: "2006-01-02"
+//	  assets: .files[*].url
+type ProvidersFile struct {
+	Providers []DeclarativeSpec `yaml:"providers"`
+}
+
+func LoadDeclarativeProviders(path string) ([]Provider, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read providers file: %w", err)
+	}
+
+	var f ProvidersFile
+	if err := yaml.Unmarshal(bs, &f); err != nil {
+		return nil, fmt.Errorf("Failed to parse providers file %q: %w", path, err)
+	}
+
+	ps := make([]Provider, 0, len(f.Providers))
+	for _, spec := range f.Providers {
+		p, err := NewDeclarativeProvider(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// RegisterDeclarativeProviders registers a provider for each spec to
+// DefaultRegistry.
+func RegisterDeclarativeProviders(specs []DeclarativeSpec) error {
+	for _, spec := range specs {
+		p, err := NewDeclarativeProvider(spec)
+		if err != nil {
+			return err
+		}
+		if err := Register(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigureDeclarativeProviders registers the providers defined in the files
+// at paths to DefaultRegistry.
+func ConfigureDeclarativeProviders(paths []string) error {
+	fetchConfigs["declarative"] = strings.Join(paths, ",")
+	for _, path := range paths {
+		ps, err := LoadDeclarativeProviders(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range ps {
+			if err := Register(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
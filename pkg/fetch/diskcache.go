@@ -0,0 +1,374 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+var diskCacheFetchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "latest",
+	Subsystem: "disk_cache",
+	Name:      "fetches_total",
+	Help:      "Total number of fetches through disk_cache by its softwareId and cache hit.",
+}, []string{"software", "cache_hit"})
+
+const (
+	diskCacheExt       = ".json"
+	diskCacheTmpPrefix = ".tmp-"
+)
+
+// DefaultDiskCacheDir returns latest/ in $XDG_CACHE_HOME, which defaults to
+// ~/.cache.
+func DefaultDiskCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "latest")
+}
+
+// fetchConfigs are the configurations affecting the fetched releases by their
+// kind, such as the credentials and the repositories queried, set by the
+// Configure* functions.
+var fetchConfigs = make(map[string]string)
+
+// fetchConfigKey digests fetchConfigs, so that releases fetched with another
+// configuration are not served from the DiskCache. The credentials in it are
+// not stored as is.
+func fetchConfigKey() string {
+	kinds := make([]string, 0, len(fetchConfigs))
+	for kind := range fetchConfigs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	h := sha256.New()
+	for _, kind := range kinds {
+		fmt.Fprintf(h, "%s=%q\n", kind, fetchConfigs[kind])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DiskCacheEntry is the content of a file in the DiskCache.
+type DiskCacheEntry struct {
+	SoftwareId string            `json:"software_id"`
+	FetchedAt  time.Time         `json:"fetched_at"`
+	ConfigKey  string            `json:"config_key,omitempty"`
+	Releases   releases.Releases `json:"releases"`
+
+	// Path and Size of the file, filled by Entries.
+	Path string `json:"-"`
+	Size int64  `json:"-"`
+}
+
+// DiskCache caches the releases fetched by Backend in files under Dir for
+// TTL, so that they are shared by processes and survive restarts. Files are
+// replaced atomically by renaming, so that concurrent processes never read
+// partially written ones. Failed fetches are not cached.
+type DiskCache struct {
+	Dir     string
+	TTL     time.Duration
+	Backend Backend
+	// ConfigKey identifies the configuration Backend fetches with. Entries
+	// stored with another ConfigKey are not served.
+	ConfigKey string
+}
+
+func (d *DiskCache) pathOf(softwareId string) string {
+	sum := sha256.Sum256([]byte(softwareId))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:])+diskCacheExt)
+}
+
+func (d *DiskCache) isExpired(e *DiskCacheEntry, now time.Time) bool {
+	return now.Sub(e.FetchedAt) > d.TTL
+}
+
+func readDiskCacheEntry(path string) (*DiskCacheEntry, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &DiskCacheEntry{}
+	if err := json.Unmarshal(bs, e); err != nil {
+		return nil, fmt.Errorf("Failed to parse cache entry %q: %w", path, err)
+	}
+	e.Path = path
+	e.Size = int64(len(bs))
+	return e, nil
+}
+
+// lookup returns the unexpired entry of softwareId, or nil if there is none.
+func (d *DiskCache) lookup(softwareId string) *DiskCacheEntry {
+	e, err := readDiskCacheEntry(d.pathOf(softwareId))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			zap.S().Debugf("Ignoring cache entry of %q: %v", softwareId, err)
+		}
+		return nil
+	}
+	// Guard against hash collisions and hand-edited files.
+	if e.SoftwareId != softwareId || d.isExpired(e, NowImpl()) {
+		return nil
+	}
+	if e.ConfigKey != d.ConfigKey {
+		zap.S().Debugf("Ignoring cache entry of %q fetched with another configuration", softwareId)
+		return nil
+	}
+	return e
+}
+
+func (d *DiskCache) store(softwareId string, rs releases.Releases) error {
+	bs, err := json.Marshal(DiskCacheEntry{
+		SoftwareId: softwareId,
+		FetchedAt:  NowImpl(),
+		ConfigKey:  d.ConfigKey,
+		Releases:   rs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return fmt.Errorf("Failed to create cache dir: %w", err)
+	}
+	f, err := ioutil.TempFile(d.Dir, diskCacheTmpPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("Failed to create cache entry: %w", err)
+	}
+	tmpPath := f.Name()
+	if _, err := f.Write(bs); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write cache entry: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, d.pathOf(softwareId)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func (d *DiskCache) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	if e := d.lookup(softwareId); e != nil {
+		zap.S().Debugf("disk cache hit: returning entry %q fetched at %v.", softwareId, e.FetchedAt)
+		diskCacheFetchesTotal.WithLabelValues(softwareId, "hit").Inc()
+		return e.Releases, nil
+	}
+	return d.fetchAndStore(ctx, softwareId)
+}
+
+func (d *DiskCache) fetchAndStore(ctx context.Context, softwareId string) (releases.Releases, error) {
+	diskCacheFetchesTotal.WithLabelValues(softwareId, "miss").Inc()
+
+	rs, err := d.Backend.Fetch(ctx, softwareId)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.store(softwareId, rs); err != nil {
+		zap.S().Warnf("Failed to cache releases of %q: %v", softwareId, err)
+	}
+	return rs, nil
+}
+
+// FetchMany returns the cached releases of softwareIds, and fetches the rest
+// at once if Backend is a BatchBackend.
+func (d *DiskCache) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+
+	var misses []string
+	for _, softwareId := range softwareIds {
+		if e := d.lookup(softwareId); e != nil {
+			diskCacheFetchesTotal.WithLabelValues(softwareId, "hit").Inc()
+			rss[softwareId] = e.Releases
+			continue
+		}
+		misses = append(misses, softwareId)
+	}
+	if len(misses) == 0 {
+		return rss, errs
+	}
+
+	bb, ok := d.Backend.(BatchBackend)
+	if !ok {
+		for _, softwareId := range misses {
+			rs, err := d.fetchAndStore(ctx, softwareId)
+			if err != nil {
+				errs[softwareId] = err
+				continue
+			}
+			rss[softwareId] = rs
+		}
+		return rss, errs
+	}
+
+	fetched, fetchErrs := bb.FetchMany(ctx, misses)
+	for _, softwareId := range misses {
+		diskCacheFetchesTotal.WithLabelValues(softwareId, "miss").Inc()
+		if err := fetchErrs[softwareId]; err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		rs := fetched[softwareId]
+		rss[softwareId] = rs
+		if err := d.store(softwareId, rs); err != nil {
+			zap.S().Warnf("Failed to cache releases of %q: %v", softwareId, err)
+		}
+	}
+	return rss, errs
+}
+
+// Entries returns the entries in the cache sorted by the softwareId. Files
+// which fail to parse are returned with only Path and Size set.
+func (d *DiskCache) Entries() ([]*DiskCacheEntry, error) {
+	fis, err := ioutil.ReadDir(d.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to read cache dir: %w", err)
+	}
+
+	var es []*DiskCacheEntry
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), diskCacheExt) || strings.HasPrefix(fi.Name(), diskCacheTmpPrefix) {
+			continue
+		}
+		path := filepath.Join(d.Dir, fi.Name())
+		e, err := readDiskCacheEntry(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed by a concurrent process.
+				continue
+			}
+			zap.S().Debugf("%v", err)
+			e = &DiskCacheEntry{Path: path, Size: fi.Size()}
+		}
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].SoftwareId < es[j].SoftwareId })
+	return es, nil
+}
+
+// IsExpired reports whether e is older than TTL.
+func (d *DiskCache) IsExpired(e *DiskCacheEntry) bool {
+	return d.isExpired(e, NowImpl())
+}
+
+func (d *DiskCache) remove(pred func(e *DiskCacheEntry) bool) (int, error) {
+	es, err := d.Entries()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range es {
+		if !pred(e) {
+			continue
+		}
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return n, fmt.Errorf("Failed to remove cache entry: %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Clear removes all the entries and returns the number removed.
+func (d *DiskCache) Clear() (int, error) {
+	return d.remove(func(*DiskCacheEntry) bool { return true })
+}
+
+// Prune removes the expired and unparsable entries and returns the number
+// removed. Temporary files left by interrupted writes are removed as well.
+func (d *DiskCache) Prune() (int, error) {
+	now := NowImpl()
+	n, err := d.remove(func(e *DiskCacheEntry) bool {
+		return e.SoftwareId == "" || d.isExpired(e, now)
+	})
+	if err != nil {
+		return n, err
+	}
+
+	tmps, err := filepath.Glob(filepath.Join(d.Dir, diskCacheTmpPrefix+"*"))
+	if err != nil {
+		return n, err
+	}
+	for _, path := range tmps {
+		// Leave the ones possibly being written by a concurrent process.
+		fi, err := os.Stat(path)
+		if err != nil || now.Sub(fi.ModTime()) < time.Hour {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return n, fmt.Errorf("Failed to remove cache entry: %w", err)
+		}
+	}
+	return n, nil
+}
+
+type DiskCacheConfig struct {
+	// Enabled makes NewFetcher cache fetched releases on disk.
+	Enabled bool
+	// Dir defaults to DefaultDiskCacheDir.
+	Dir string
+	TTL time.Duration
+}
+
+var diskCacheConfig = DiskCacheConfig{TTL: time.Hour}
+
+// ConfigureDiskCache sets up the DiskCache returned by OpenDiskCache and used
+// by NewFetcher.
+func ConfigureDiskCache(cfg DiskCacheConfig) error {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDiskCacheDir()
+	}
+	if cfg.Enabled && cfg.Dir == "" {
+		return fmt.Errorf("Failed to determine the disk cache dir: specify one explicitly")
+	}
+	if cfg.TTL <= 0 {
+		return fmt.Errorf("Disk cache TTL must be positive, got %v", cfg.TTL)
+	}
+	diskCacheConfig = cfg
+	return nil
+}
+
+// DisableDiskCache makes NewFetcher fetch without the DiskCache, leaving the
+// rest of the configuration for OpenDiskCache.
+func DisableDiskCache() {
+	diskCacheConfig.Enabled = false
+}
+
+// OpenDiskCache returns the DiskCache as configured by ConfigureDiskCache,
+// fetching from Direct on misses, regardless of whether it is enabled. Its
+// entries are keyed on the configuration of the other Configure* functions
+// called so far.
+func OpenDiskCache() *DiskCache {
+	dir := diskCacheConfig.Dir
+	if dir == "" {
+		dir = DefaultDiskCacheDir()
+	}
+	return &DiskCache{Dir: dir, TTL: diskCacheConfig.TTL, Backend: Direct{}, ConfigKey: fetchConfigKey()}
+}
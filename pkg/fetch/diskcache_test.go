@@ -0,0 +1,112 @@
+package fetch_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// countingBackend returns a release of version "1.0.<number of fetches>" for
+// softwareIds other than "broken".
+type countingBackend struct {
+	fetches map[string]int
+}
+
+func (b *countingBackend) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	if softwareId == "broken" {
+		return nil, errors.New("broken")
+	}
+	b.fetches[softwareId]++
+	v := semver.Version{Major: 1, Patch: uint64(b.fetches[softwareId])}
+	return releases.Releases{{OriginalName: v.String(), Version: v, Assets: []releases.Asset{}}}, nil
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	fetch.NowImpl = func() time.Time { return now }
+	defer func() { fetch.NowImpl = time.Now }()
+
+	b := &countingBackend{fetches: map[string]int{}}
+	d := &fetch.DiskCache{Dir: filepath.Join(dir, "latest"), TTL: time.Hour, Backend: b}
+	// A second process sharing the same dir.
+	d2 := &fetch.DiskCache{Dir: d.Dir, TTL: time.Hour, Backend: b}
+
+	versionOf := func(d *fetch.DiskCache, softwareId string) string {
+		t.Helper()
+		rs, err := d.Fetch(context.Background(), softwareId)
+		if err != nil {
+			t.Fatalf("Fetch(%q): %v", softwareId, err)
+		}
+		return rs[0].Version.String()
+	}
+
+	if v := versionOf(d, "foo"); v != "1.0.1" {
+		t.Errorf("first fetch: %s", v)
+	}
+	if v := versionOf(d2, "foo"); v != "1.0.1" {
+		t.Errorf("should be served from the cache: %s", v)
+	}
+	if _, err := d.Fetch(context.Background(), "broken"); err == nil {
+		t.Errorf("expected error")
+	}
+	// e.g. authenticated after caching an unauthenticated fetch.
+	d3 := &fetch.DiskCache{Dir: d.Dir, TTL: time.Hour, Backend: b, ConfigKey: "other"}
+	if v := versionOf(d3, "foo"); v != "1.0.2" {
+		t.Errorf("entry fetched with another configuration should be refetched: %s", v)
+	}
+	if v := versionOf(d, "foo"); v != "1.0.3" {
+		t.Errorf("entry fetched with another configuration should be refetched: %s", v)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if v := versionOf(d2, "foo"); v != "1.0.4" {
+		t.Errorf("expired entry should be refetched: %s", v)
+	}
+	now = now.Add(30 * time.Minute)
+	if v := versionOf(d, "bar"); v != "1.0.1" {
+		t.Errorf("first fetch: %s", v)
+	}
+
+	es, err := d.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range es {
+		ids = append(ids, e.SoftwareId)
+	}
+	if diff := cmp.Diff([]string{"bar", "foo"}, ids); diff != "" {
+		t.Errorf("Entries: failed errors are not cached (-want +got):\n%s", diff)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(d.Dir, "garbage.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Expires "foo" but not "bar".
+	now = now.Add(45 * time.Minute)
+	if n, err := d.Prune(); err != nil || n != 2 {
+		t.Errorf("Prune: %d, %v", n, err)
+	}
+	if n, err := d.Clear(); err != nil || n != 1 {
+		t.Errorf("Clear: %d, %v", n, err)
+	}
+	if es, err := d.Entries(); err != nil || len(es) != 0 {
+		t.Errorf("Entries after Clear: %v, %v", es, err)
+	}
+}
@@ -0,0 +1,163 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// ExecRequest is written to the stdin of the command of an ExecProvider.
+type ExecRequest struct {
+	// SoftwareId includes the prefix, e.g. "vendor:product".
+	SoftwareId string `json:"software_id"`
+}
+
+// ExecRelease is a release as read from the stdout of the command. It is the
+// JSON form of releases.Release, except that the version may be omitted, in
+// which case it is parsed from original_name.
+type ExecRelease struct {
+	OriginalName string           `json:"original_name"`
+	Version      string           `json:"version,omitempty"`
+	Prerelease   bool             `json:"prerelease"`
+	Assets       []releases.Asset `json:"assets"`
+	PublishedAt  time.Time        `json:"published_at"`
+	Notes        string           `json:"notes,omitempty"`
+	ChangelogURL string           `json:"changelog_url,omitempty"`
+	Source       string           `json:"source,omitempty"`
+}
+
+// ExecResponse is read from the stdout of the command. A non-empty Error
+// fails the fetch.
+type ExecResponse struct {
+	Releases []ExecRelease `json:"releases"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ExecProvider fetches releases by running an external command, which reads
+// an ExecRequest from stdin and writes an ExecResponse to stdout. It handles
+// the softwareIds starting with "PREFIX:".
+type ExecProvider struct {
+	prefix  string
+	command []string
+}
+
+func NewExecProvider(prefix string, command []string) (*ExecProvider, error) {
+	if prefix == "" || strings.ContainsAny(prefix, ":@<>=") {
+		return nil, fmt.Errorf("Invalid provider prefix %q", prefix)
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("No command specified for provider %q", prefix)
+	}
+	return &ExecProvider{prefix: prefix, command: command}, nil
+}
+
+// ParseExecProviderSpec parses "PREFIX=COMMAND [ARGS...]". The command line
+// is split at whitespace.
+func ParseExecProviderSpec(spec string) (*ExecProvider, error) {
+	ss := strings.SplitN(spec, "=", 2)
+	if len(ss) != 2 {
+		return nil, fmt.Errorf("Failed to parse provider spec %q: expected PREFIX=COMMAND", spec)
+	}
+	return NewExecProvider(ss[0], strings.Fields(ss[1]))
+}
+
+func (p *ExecProvider) Name() string       { return "exec:" + p.prefix }
+func (p *ExecProvider) Prefixes() []string { return []string{p.prefix} }
+
+func (p *ExecProvider) Match(softwareId string) bool {
+	return strings.HasPrefix(softwareId, p.prefix+":")
+}
+
+// Capabilities are unknown until the command is run, so all are claimed.
+func (p *ExecProvider) Capabilities() Capabilities {
+	return Capabilities{Assets: true, Checksums: true, Signatures: true, Platforms: true, PublishedAt: true, Notes: true, Changelog: true}
+}
+
+func (p *ExecProvider) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	l := zap.S()
+
+	reqbs, err := json.Marshal(ExecRequest{SoftwareId: softwareId})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal request: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdin = bytes.NewReader(reqbs)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Provider command %q failed: %w: %s", p.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if stderr.Len() > 0 {
+		l.Debugf("Provider command %q stderr: %s", p.command[0], strings.TrimSpace(stderr.String()))
+	}
+
+	var resp ExecResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse the output of provider command %q: %w", p.command[0], err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Provider command %q: %s", p.command[0], resp.Error)
+	}
+
+	rs := make(releases.Releases, 0, len(resp.Releases))
+	for _, er := range resp.Releases {
+		verStr := er.Version
+		if verStr == "" {
+			verStr = er.OriginalName
+		}
+		ver, err := parser.ParseVersion(verStr)
+		if err != nil {
+			l.Debugf("Failed to parse version %q from provider command %q, leaving it to the version scheme: %v", verStr, p.command[0], err)
+		}
+
+		for i, a := range er.Assets {
+			if a.Name == "" {
+				er.Assets[i].Name = releases.NewAsset(a.URL).Name
+			}
+		}
+
+		rs = append(rs, releases.Release{
+			OriginalName:  er.OriginalName,
+			Version:       ver,
+			Components:    parser.ParseComponents(verStr),
+			VersionString: er.Version,
+			Unparsed:      err != nil,
+			Prerelease:    er.Prerelease || len(ver.Pre) > 0,
+			Assets:        er.Assets,
+			PublishedAt:   er.PublishedAt,
+			Notes:         er.Notes,
+			ChangelogURL:  er.ChangelogURL,
+			Source:        er.Source,
+		})
+	}
+	rs.Sort()
+
+	return rs, nil
+}
+
+// ConfigureExecProviders registers an ExecProvider to DefaultRegistry for
+// each "PREFIX=COMMAND" spec.
+func ConfigureExecProviders(specs []string) error {
+	fetchConfigs["exec"] = strings.Join(specs, "\n")
+	for _, spec := range specs {
+		p, err := ParseExecProviderSpec(spec)
+		if err != nil {
+			return err
+		}
+		if err := Register(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
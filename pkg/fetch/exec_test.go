@@ -0,0 +1,64 @@
+package fetch_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+)
+
+const execScript = `
+req=$(cat)
+case "$req" in
+*'"software_id":"vendor:foo"'*)
+	echo '{"releases": [{"original_name": "1.0"}, {"original_name": "v2.0.0-rc1", "assets": [{"url": "https://example.com/foo-2.0.0-rc1.tar.gz"}]}, {"original_name": "1.1", "version": "1.1.0"}]}' ;;
+*)
+	echo '{"error": "unknown product"}' ;;
+esac
+`
+
+func TestExecProvider(t *testing.T) {
+	p, err := fetch.NewExecProvider("vendor", []string{"sh", "-c", execScript})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Match("vendor:foo") || p.Match("github.com/vendor/foo") {
+		t.Errorf("Unexpected Match result")
+	}
+
+	rs, err := p.Fetch(context.Background(), "vendor:foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rs {
+		names = append(names, r.OriginalName)
+	}
+	if diff := cmp.Diff([]string{"v2.0.0-rc1", "1.1", "1.0"}, names); diff != "" {
+		t.Errorf("Unexpected releases (-want +got):\n%s", diff)
+	}
+	if !rs[0].Prerelease || rs[0].Assets[0].Name != "foo-2.0.0-rc1.tar.gz" {
+		t.Errorf("Unexpected release: %+v", rs[0])
+	}
+
+	if _, err := p.Fetch(context.Background(), "vendor:bar"); err == nil {
+		t.Errorf("Expected the error reported by the command")
+	}
+}
+
+func TestParseExecProviderSpec(t *testing.T) {
+	for _, spec := range []string{"vendor", "=cmd", "vendor=", "a:b=cmd"} {
+		if _, err := fetch.ParseExecProviderSpec(spec); err == nil {
+			t.Errorf("ParseExecProviderSpec(%q): expected error", spec)
+		}
+	}
+	p, err := fetch.ParseExecProviderSpec("vendor=/usr/local/bin/latest-vendor --verbose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"vendor"}, p.Prefixes()); diff != "" {
+		t.Errorf("Unexpected prefixes (-want +got):\n%s", diff)
+	}
+}
@@ -2,29 +2,19 @@ package fetch
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
-	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/apache"
-	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
 	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/github"
-	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/goruntime"
-	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/hashicorp"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
 	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/maven"
 	"github.com/IPA-CyberLab/latest/pkg/releases"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"go.uber.org/zap"
 )
 
-var fetchImpls = []func(ctx context.Context, softwareId string) (releases.Releases, error){
-	hashicorp.Fetch,
-	goruntime.Fetch,
-	apache.Fetch,
-	maven.Fetch,
-	github.Fetch,
-}
-
 var directSecondsHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
 	Namespace: "latest",
 	Subsystem: "direct_fetcher",
@@ -33,9 +23,20 @@ var directSecondsHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
 	Help: "Seconds took to fetch releases by softwareId. Recorded only on fetch success.",
 }, []string{"software"})
 
-type Direct struct{}
+// Direct fetches releases from the providers in Registry, or in
+// DefaultRegistry if nil.
+type Direct struct {
+	Registry *Registry
+}
+
+func (d Direct) registry() *Registry {
+	if d.Registry == nil {
+		return DefaultRegistry
+	}
+	return d.Registry
+}
 
-func (Direct) Fetch(ctx context.Context, softwareId string) (rs releases.Releases, err error) {
+func (d Direct) Fetch(ctx context.Context, softwareId string) (rs releases.Releases, err error) {
 	start := time.Now()
 	defer func() {
 		if err == nil {
@@ -43,18 +44,141 @@ func (Direct) Fetch(ctx context.Context, softwareId string) (rs releases.Release
 		}
 	}()
 
-	for _, fetchImpl := range fetchImpls {
-		rs, err = fetchImpl(ctx, softwareId)
-		if err == nil {
-			return
-		} else {
-			var parseErr ferrors.ErrSoftwareIdParseFailed
-			if !errors.As(err, &parseErr) {
-				return
-			}
-			zap.S().Debugf("%s", err)
+	return d.registry().Fetch(ctx, softwareId)
+}
+
+// FetchMany fetches the releases of all softwareIds. The softwareIds of
+// BatchProviders, such as GitHub repositories when authenticated, are fetched
+// in batches, and the rest are fetched one by one as Fetch does.
+func (d Direct) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	return d.registry().FetchMany(ctx, softwareIds)
+}
+
+// NewFetcher returns the Backend the commands fetch from: Direct, behind
+// the DiskCache if enabled by ConfigureDiskCache, and behind the snapshot if
+// loaded by ConfigureOffline.
+func NewFetcher() Backend {
+	var backend Backend = Direct{}
+	if diskCacheConfig.Enabled {
+		backend = OpenDiskCache()
+	}
+	if loadedSnapshot != nil {
+		if offlineConfig.Offline {
+			return NewSnapshotFetcher(loadedSnapshot, nil)
+		}
+		return NewSnapshotFetcher(loadedSnapshot, backend)
+	}
+	return backend
+}
+
+type HTTPConfig struct {
+	// Timeout of each attempt of a request. Zero keeps the default.
+	Timeout time.Duration
+	// HostTimeouts override Timeout for the hosts, each specified as
+	// "HOST=DURATION".
+	HostTimeouts []string
+
+	// MaxRetries on network errors, 5xx and 429 responses. Negative keeps
+	// the default.
+	MaxRetries int
+
+	// CAFile is a PEM bundle of CA certificates trusted in addition to the
+	// system ones. ClientCertFile and ClientKeyFile are presented to the
+	// servers requesting client certificates.
+	CAFile         string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// ConfigureHTTP sets up the HTTP client shared by the providers. Proxies are
+// taken from $HTTP_PROXY, $HTTPS_PROXY and $NO_PROXY.
+func ConfigureHTTP(cfg HTTPConfig) error {
+	if cfg.Timeout > 0 {
+		httpcli.Timeout = cfg.Timeout
+	}
+	for _, spec := range cfg.HostTimeouts {
+		ss := strings.SplitN(spec, "=", 2)
+		if len(ss) != 2 {
+			return fmt.Errorf("Failed to parse host timeout %q: expected HOST=DURATION", spec)
 		}
+		d, err := time.ParseDuration(ss[1])
+		if err != nil {
+			return fmt.Errorf("Failed to parse host timeout %q: %w", spec, err)
+		}
+		httpcli.HostTimeouts[ss[0]] = d
+	}
+	if cfg.MaxRetries >= 0 {
+		httpcli.MaxRetries = cfg.MaxRetries
 	}
+	return httpcli.ConfigureTLS(cfg.CAFile, cfg.ClientCertFile, cfg.ClientKeyFile)
+}
+
+func DefaultMavenSettingsPath() string {
+	return maven.DefaultSettingsPath()
+}
+
+type MavenConfig struct {
+	// SettingsPath is the path to a Maven settings.xml.
+	SettingsPath string
+	// Repositories is a comma separated list of "id=url" pairs, which
+	// overrides SettingsPath if non-empty.
+	Repositories string
+	// ResolveSnapshots resolves "-SNAPSHOT" versions to their latest
+	// timestamped artifact.
+	ResolveSnapshots bool
+}
+
+// ConfigureMaven sets the repositories queried by the "m2:" softwareIds.
+// See maven.Configure for details.
+func ConfigureMaven(cfg MavenConfig) error {
+	maven.ResolveSnapshots = cfg.ResolveSnapshots
+	if err := maven.Configure(cfg.SettingsPath, cfg.Repositories); err != nil {
+		return err
+	}
+	fetchConfigs["maven"] = fmt.Sprintf("%+v %t", maven.Repositories, cfg.ResolveSnapshots)
+	return nil
+}
+
+type GitHubConfig struct {
+	// Credentials for github.com.
+	Token     string
+	TokenFile string
 
-	return
+	AppId             int64
+	AppInstallationId int64
+	AppPrivateKeyFile string
+
+	// MaxPages bounds the number of pages read from paginated listings.
+	// Zero keeps the default.
+	MaxPages int
+
+	// EnterpriseHosts are GitHub Enterprise Server hosts to recognize in
+	// softwareIds, each specified as "HOST" or "HOST=APIBASE". Their tokens
+	// are read from $LATEST_GITHUB_TOKEN_{HOST}.
+	EnterpriseHosts []string
+}
+
+// ConfigureGitHub sets the credentials and hosts used for the GitHub API calls.
+func ConfigureGitHub(cfg GitHubConfig) error {
+	gh := github.Hosts[github.DefaultHostName]
+	auth, err := github.NewAuth(gh.APIBase, cfg.Token, cfg.TokenFile, cfg.AppId, cfg.AppInstallationId, cfg.AppPrivateKeyFile)
+	if err != nil {
+		return err
+	}
+	gh.Auth = auth
+
+	if cfg.MaxPages > 0 {
+		github.MaxPages = cfg.MaxPages
+	}
+
+	key := fmt.Sprintf("%+v", cfg)
+	for _, spec := range cfg.EnterpriseHosts {
+		if err := github.RegisterEnterpriseHost(spec); err != nil {
+			return err
+		}
+		name, _, _ := github.ParseHostSpec(spec)
+		key += fmt.Sprintf(" %s=%q", name, os.Getenv(github.TokenEnvName(name)))
+	}
+	fetchConfigs["github"] = key
+	return nil
 }
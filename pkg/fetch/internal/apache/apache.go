@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
 	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
@@ -38,11 +40,73 @@ func parse(softwareId string) (ParsedId, error) {
 
 func Match(softwareId string) bool {
 	_, err := parse(softwareId)
-	return err != nil
+	return err == nil
 }
 
 const endpoint = "https://projects.apache.org/json/foundation/releases.json"
 
+// distBase is where the files of all releases are kept, each project in its
+// own directory.
+const distBase = "https://archive.apache.org/dist/"
+
+var reListingHref = regexp.MustCompile(`href="([^"/?#][^"/?#]*)"`)
+
+// parseListing returns the set of file names in a directory index page.
+func parseListing(html string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, ms := range reListingHref.FindAllStringSubmatch(html, -1) {
+		names[ms[1]] = struct{}{}
+	}
+	return names
+}
+
+// Apache releases are signed, and published with checksums next to them.
+// https://www.apache.org/info/verification.html
+var checksumAlgorithms = []string{"sha1", "sha256", "sha512"}
+
+var sidecarSuffixes = []string{".asc", ".sig", ".md5", ".sha", ".sha1", ".sha256", ".sha512"}
+
+// assetsOf returns the files of the release in the listing of dirURL, i.e.
+// the ones named releaseName followed by "." or "-", along with their
+// signature and checksums.
+func assetsOf(names map[string]struct{}, dirURL, releaseName string) []releases.Asset {
+	var files []string
+	for name := range names {
+		if !strings.HasPrefix(name, releaseName+".") && !strings.HasPrefix(name, releaseName+"-") {
+			continue
+		}
+		sidecar := false
+		for _, suffix := range sidecarSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				sidecar = true
+				break
+			}
+		}
+		if !sidecar {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	as := make([]releases.Asset, 0, len(files))
+	for _, name := range files {
+		a := releases.NewAsset(dirURL + name)
+		if _, ok := names[name+".asc"]; ok {
+			a.SignatureURL = a.URL + ".asc"
+		}
+		for _, algo := range checksumAlgorithms {
+			if _, ok := names[name+"."+algo]; ok {
+				if a.ChecksumURLs == nil {
+					a.ChecksumURLs = make(map[string]string)
+				}
+				a.ChecksumURLs[algo] = a.URL + "." + algo
+			}
+		}
+		as = append(as, a)
+	}
+	return as
+}
+
 func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 	l := zap.S()
 
@@ -66,33 +130,47 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 		return nil, fmt.Errorf("Failed to find an Apache project named %q", parsed.ProjectName)
 	}
 
+	// Only the releases kept directly in the project directory have assets,
+	// as the layout of the subdirectories differs by project.
+	dirURL := distBase + parsed.ProjectName + "/"
+	var names map[string]struct{}
+	if bs, err := httpcli.Get(ctx, dirURL); err == nil {
+		names = parseListing(string(bs))
+	} else {
+		l.Debugf("Failed to list the files of %q: %v", parsed.ProjectName, err)
+	}
+
 	rs := make(releases.Releases, 0)
 
 	for releaseName, dateStr := range projReleases {
-		_ = dateStr // FIXME
-
 		component, ver, err := parser.ParseComponentAndVersion(releaseName)
 		if err != nil {
 			l.Debugf("Failed to parse release version of %q: %v", releaseName, err)
-			continue
-		}
-
-		if parsed.Component != "" && component != parsed.Component {
+			// The component is unknown as well.
+			if parsed.Component != "" {
+				continue
+			}
+		} else if parsed.Component != "" && component != parsed.Component {
 			continue
 		}
 
 		r := releases.Release{
 			OriginalName: releaseName,
 			Version:      ver,
+			Components:   parser.ParseComponents(releaseName),
+			Unparsed:     err != nil,
 			Prerelease:   false,
-			AssetURLs:    nil, // FIXME
+			Assets:       assetsOf(names, dirURL, releaseName),
+		}
+		if publishedAt, err := time.Parse("2006-01-02", dateStr); err == nil {
+			r.PublishedAt = publishedAt
+		} else {
+			l.Debugf("Failed to parse release date of %q: %v", releaseName, err)
 		}
 		rs = append(rs, r)
 	}
 
-	sort.SliceStable(rs, func(i, j int) bool {
-		return rs[i].Version.GT(rs[j].Version)
-	})
+	rs.Sort()
 
 	return rs, nil
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/releases"
 )
 
 func TestParse(t *testing.T) {
@@ -36,3 +38,36 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestAssetsOf(t *testing.T) {
+	names := parseListing(`<html><body><h1>Index of /dist/httpd</h1>
+<a href="?C=N;O=D">Name</a> <a href="/dist/">Parent Directory</a>
+<a href="docs/">docs/</a>
+<a href="httpd-2.4.57.tar.gz">httpd-2.4.57.tar.gz</a>
+<a href="httpd-2.4.57.tar.gz.asc">httpd-2.4.57.tar.gz.asc</a>
+<a href="httpd-2.4.57.tar.gz.sha256">httpd-2.4.57.tar.gz.sha256</a>
+<a href="httpd-2.4.57.tar.gz.sha512">httpd-2.4.57.tar.gz.sha512</a>
+<a href="httpd-2.4.57-deps.tar.bz2">httpd-2.4.57-deps.tar.bz2</a>
+<a href="httpd-2.4.5.tar.gz">httpd-2.4.5.tar.gz</a>
+</body></html>`)
+
+	const dir = "https://archive.apache.org/dist/httpd/"
+	expected := []releases.Asset{
+		{
+			Name: "httpd-2.4.57-deps.tar.bz2",
+			URL:  dir + "httpd-2.4.57-deps.tar.bz2",
+		},
+		{
+			Name: "httpd-2.4.57.tar.gz",
+			URL:  dir + "httpd-2.4.57.tar.gz",
+			ChecksumURLs: map[string]string{
+				"sha256": dir + "httpd-2.4.57.tar.gz.sha256",
+				"sha512": dir + "httpd-2.4.57.tar.gz.sha512",
+			},
+			SignatureURL: dir + "httpd-2.4.57.tar.gz.asc",
+		},
+	}
+	if diffstr := cmp.Diff(expected, assetsOf(names, dir, "httpd-2.4.57")); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+}
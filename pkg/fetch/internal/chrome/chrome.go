@@ -0,0 +1,159 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const HandlerName = "chrome"
+
+const versionHistoryEndpoint = "https://versionhistory.googleapis.com/v1/chrome/platforms/all/channels"
+
+// https://developer.chrome.com/docs/web-platform/versionhistory/reference
+var Channels = map[string]struct{}{
+	"stable":   {},
+	"extended": {},
+	"beta":     {},
+	"dev":      {},
+	"canary":   {},
+}
+
+var reChrome = regexp.MustCompile(`^[Cc]hrome(:(\w+))?$`)
+
+func parseChannel(softwareId string) (string, bool) {
+	ms := reChrome.FindStringSubmatch(softwareId)
+	if len(ms) == 0 {
+		return "", false
+	}
+
+	channel := strings.ToLower(ms[2])
+	if channel == "" {
+		channel = "stable"
+	}
+	if _, ok := Channels[channel]; !ok {
+		return "", false
+	}
+	return channel, true
+}
+
+func Match(softwareId string) bool {
+	_, ok := parseChannel(softwareId)
+	return ok
+}
+
+var reChromeVersion = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)\.(\d+)$`)
+
+// ParseVersion parses a Chrome version string "MAJOR.MINOR.BUILD.PATCH".
+// The BUILD number is mapped to the semver patch, and the Chrome PATCH number
+// is kept as the semver build metadata so that the original string can be
+// reconstructed.
+func ParseVersion(s string) (semver.Version, error) {
+	ms := reChromeVersion.FindStringSubmatch(s)
+	if len(ms) == 0 {
+		return semver.Version{}, fmt.Errorf("Failed to parse Chrome version %q", s)
+	}
+
+	var ns [3]uint64
+	for i := range ns {
+		n, err := strconv.ParseUint(ms[i+1], 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("Failed to parse Chrome version %q: %w", s, err)
+		}
+		ns[i] = n
+	}
+
+	return semver.Version{
+		Major: ns[0],
+		Minor: ns[1],
+		Patch: ns[2],
+		Build: []string{ms[4]},
+	}, nil
+}
+
+// SortReleases sorts rs newest first, taking the Chrome PATCH number into
+// account as well.
+func SortReleases(rs releases.Releases) {
+	rs.Sort()
+}
+
+func Parse(jsonbs []byte) (releases.Releases, error) {
+	l := zap.S()
+
+	type RawVersion struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	type Response struct {
+		Versions      []RawVersion `json:"versions"`
+		NextPageToken string       `json:"nextPageToken"`
+	}
+
+	var resp Response
+	if err := json.Unmarshal(jsonbs, &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	rs := make(releases.Releases, 0, len(resp.Versions))
+	seen := make(map[string]struct{})
+	for _, rawv := range resp.Versions {
+		if _, ok := seen[rawv.Version]; ok {
+			continue
+		}
+		seen[rawv.Version] = struct{}{}
+
+		ver, err := ParseVersion(rawv.Version)
+		if err != nil {
+			l.Warnf("%v", err)
+			continue
+		}
+
+		// The channel is always explicitly selected by the softwareId, so
+		// the releases in it are not considered to be prereleases.
+		r := releases.Release{
+			OriginalName: rawv.Version,
+			Version:      ver,
+			Components:   parser.ParseComponents(rawv.Version),
+			Prerelease:   false,
+			Assets:       nil,
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	channel, ok := parseChannel(softwareId)
+	if !ok {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: HandlerName,
+			Err:         nil,
+		}
+	}
+
+	url := fmt.Sprintf("%s/%s/versions?pageSize=1000", versionHistoryEndpoint, channel)
+	bs, err := httpcli.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := Parse(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	SortReleases(rs)
+	return rs, nil
+}
@@ -0,0 +1,86 @@
+package chrome_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/chrome"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+func TestMatch(t *testing.T) {
+	testcases := []struct {
+		input       string
+		expectMatch bool
+	}{
+		{"chrome", true},
+		{"chrome:stable", true},
+		{"chrome:canary", true},
+		{"chrome:nosuchchannel", false},
+		{"chrome-for-testing", false},
+		{"chromium", false},
+	}
+
+	for _, tc := range testcases {
+		actual := chrome.Match(tc.input)
+		if actual != tc.expectMatch {
+			t.Errorf("Match(%q) expected %t actual %t", tc.input, tc.expectMatch, actual)
+		}
+	}
+}
+
+func TestParseKnownGood(t *testing.T) {
+	jsonstr := `{
+		"timestamp": "2024-01-25T08:09:44.477Z",
+		"versions": [
+			{
+				"version": "121.0.6167.85",
+				"revision": "1233107",
+				"downloads": {
+					"chromedriver": [
+						{"platform": "linux64", "url": "https://example.com/121.0.6167.85/linux64/chromedriver-linux64.zip"}
+					],
+					"chrome": [
+						{"platform": "linux64", "url": "https://example.com/121.0.6167.85/linux64/chrome-linux64.zip"},
+						{"platform": "mac-arm64", "url": "https://example.com/121.0.6167.85/mac-arm64/chrome-mac-arm64.zip"}
+					]
+				}
+			},
+			{
+				"version": "121.0.6167.184",
+				"revision": "1233107",
+				"downloads": {}
+			},
+			{
+				"version": "120.0.6099.109",
+				"revision": "1217362",
+				"downloads": {}
+			}
+		]
+	}`
+
+	rs, err := chrome.ParseKnownGood([]byte(jsonstr))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	chrome.SortReleases(rs)
+
+	names := make([]string, 0, len(rs))
+	for _, r := range rs {
+		names = append(names, r.OriginalName)
+	}
+	expected := []string{"121.0.6167.184", "121.0.6167.85", "120.0.6099.109"}
+	if diffstr := cmp.Diff(names, expected); diffstr != "" {
+		t.Errorf("Unexpected order: %s", diffstr)
+	}
+
+	expectedAssets := []releases.Asset{
+		{Name: "chrome-linux64.zip", URL: "https://example.com/121.0.6167.85/linux64/chrome-linux64.zip", OS: "linux", Arch: "amd64", Kind: "chrome"},
+		{Name: "chrome-mac-arm64.zip", URL: "https://example.com/121.0.6167.85/mac-arm64/chrome-mac-arm64.zip", OS: "darwin", Arch: "arm64", Kind: "chrome"},
+		{Name: "chromedriver-linux64.zip", URL: "https://example.com/121.0.6167.85/linux64/chromedriver-linux64.zip", OS: "linux", Arch: "amd64", Kind: "chromedriver"},
+	}
+	if diffstr := cmp.Diff(expectedAssets, rs[1].Assets); diffstr != "" {
+		t.Errorf("Unexpected assets (-want +got):\n%s", diffstr)
+	}
+}
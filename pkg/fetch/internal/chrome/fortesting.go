@@ -0,0 +1,172 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const ForTestingHandlerName = "chrome-for-testing"
+
+// https://github.com/GoogleChromeLabs/chrome-for-testing#json-api-endpoints
+const (
+	knownGoodEndpoint     = "https://googlechromelabs.github.io/chrome-for-testing/known-good-versions-with-downloads.json"
+	lastKnownGoodEndpoint = "https://googlechromelabs.github.io/chrome-for-testing/last-known-good-versions-with-downloads.json"
+)
+
+var reForTesting = regexp.MustCompile(`^[Cc]hrome-for-testing(:(\w+))?$`)
+
+func MatchForTesting(softwareId string) bool {
+	return reForTesting.MatchString(softwareId)
+}
+
+type download struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+}
+
+type forTestingVersion struct {
+	Version   string                `json:"version"`
+	Revision  string                `json:"revision"`
+	Downloads map[string][]download `json:"downloads"`
+}
+
+// platforms maps the platforms of the downloads to GOOS and GOARCH.
+var platforms = map[string][2]string{
+	"linux64":   {"linux", "amd64"},
+	"mac-arm64": {"darwin", "arm64"},
+	"mac-x64":   {"darwin", "amd64"},
+	"win32":     {"windows", "386"},
+	"win64":     {"windows", "amd64"},
+}
+
+func (d download) asset(binary string) releases.Asset {
+	a := releases.NewAsset(d.URL)
+	if p, ok := platforms[d.Platform]; ok {
+		a.OS, a.Arch = p[0], p[1]
+	}
+	a.Kind = binary
+	return a
+}
+
+func (v forTestingVersion) toRelease() (releases.Release, error) {
+	ver, err := ParseVersion(v.Version)
+	if err != nil {
+		return releases.Release{}, err
+	}
+
+	// Iterate over binaries ("chrome", "chromedriver", "chrome-headless-shell")
+	// in a stable order.
+	binaries := make([]string, 0, len(v.Downloads))
+	for binary := range v.Downloads {
+		binaries = append(binaries, binary)
+	}
+	sort.Strings(binaries)
+
+	assets := make([]releases.Asset, 0)
+	for _, binary := range binaries {
+		for _, d := range v.Downloads[binary] {
+			assets = append(assets, d.asset(binary))
+		}
+	}
+
+	return releases.Release{
+		OriginalName: v.Version,
+		Version:      ver,
+		Components:   parser.ParseComponents(v.Version),
+		Prerelease:   false,
+		Assets:       assets,
+	}, nil
+}
+
+func ParseKnownGood(jsonbs []byte) (releases.Releases, error) {
+	l := zap.S()
+
+	type Response struct {
+		Versions []forTestingVersion `json:"versions"`
+	}
+
+	var resp Response
+	if err := json.Unmarshal(jsonbs, &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	rs := make(releases.Releases, 0, len(resp.Versions))
+	for _, v := range resp.Versions {
+		r, err := v.toRelease()
+		if err != nil {
+			l.Warnf("%v", err)
+			continue
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func ParseLastKnownGood(jsonbs []byte, channel string) (releases.Releases, error) {
+	type Response struct {
+		Channels map[string]forTestingVersion `json:"channels"`
+	}
+
+	var resp Response
+	if err := json.Unmarshal(jsonbs, &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	for name, v := range resp.Channels {
+		if !strings.EqualFold(name, channel) {
+			continue
+		}
+
+		r, err := v.toRelease()
+		if err != nil {
+			return nil, err
+		}
+		return releases.Releases{r}, nil
+	}
+	return nil, fmt.Errorf("Failed to find a Chrome for Testing channel named %q", channel)
+}
+
+func FetchForTesting(ctx context.Context, softwareId string) (releases.Releases, error) {
+	ms := reForTesting.FindStringSubmatch(softwareId)
+	if len(ms) == 0 {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: ForTestingHandlerName,
+			Err:         nil,
+		}
+	}
+	channel := ms[2]
+
+	var rs releases.Releases
+	if channel == "" {
+		bs, err := httpcli.Get(ctx, knownGoodEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		if rs, err = ParseKnownGood(bs); err != nil {
+			return nil, err
+		}
+	} else {
+		bs, err := httpcli.Get(ctx, lastKnownGoodEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		if rs, err = ParseLastKnownGood(bs, channel); err != nil {
+			return nil, err
+		}
+	}
+
+	SortReleases(rs)
+	return rs, nil
+}
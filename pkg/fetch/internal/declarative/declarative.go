@@ -0,0 +1,314 @@
+// Package declarative implements providers defined by a Spec: a URL to fetch
+// and selectors to extract releases from the response.
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// Spec defines a provider for the softwareIds "PREFIX:NAME".
+//
+// URL and AssetTemplates are text/templates, executed with .SoftwareId,
+// .Name and .Args, the ":" separated elements of the name. AssetTemplates
+// also have .Version, the version string extracted.
+//
+// Releases selects each release in the response, and the other selectors are
+// evaluated relative to it:
+//   - format "json": JSONPath or jq paths, e.g. "$.releases[*]" and ".version".
+//   - format "xml": XPath, e.g. "//versioning/versions/version" and "text()".
+//   - format "html": Releases is a regexp matched against the whole
+//     response, and the other selectors name its capture groups.
+type Spec struct {
+	Name   string `yaml:"name" json:"name"`
+	Prefix string `yaml:"prefix" json:"prefix"`
+	URL    string `yaml:"url" json:"url"`
+	Format string `yaml:"format" json:"format"`
+
+	Releases string `yaml:"releases" json:"releases"`
+	Version  string `yaml:"version" json:"version"`
+	// VersionRegexp, if set, extracts the version from the selected string
+	// with its first capture group, e.g. `^v?([\d.]+)$`.
+	VersionRegexp string `yaml:"version_regexp,omitempty" json:"version_regexp,omitempty"`
+	// Prerelease selects a value which marks a prerelease if "true", "yes"
+	// or "1". Versions with a semver prerelease are always prereleases.
+	Prerelease string `yaml:"prerelease,omitempty" json:"prerelease,omitempty"`
+	Date       string `yaml:"date,omitempty" json:"date,omitempty"`
+	// DateLayout is in the notation of time.Parse. Defaults to RFC3339.
+	DateLayout     string   `yaml:"date_layout,omitempty" json:"date_layout,omitempty"`
+	Assets         string   `yaml:"assets,omitempty" json:"assets,omitempty"`
+	AssetTemplates []string `yaml:"asset_templates,omitempty" json:"asset_templates,omitempty"`
+}
+
+type format interface {
+	parse(bs []byte) (interface{}, error)
+	selectNodes(n interface{}, expr string) ([]interface{}, error)
+	text(n interface{}) string
+}
+
+var formats = map[string]format{
+	"json": jsonFormat{},
+	"xml":  xmlFormat{},
+	"html": htmlFormat{},
+}
+
+// Provider fetches releases as defined by its Spec.
+type Provider struct {
+	Spec Spec
+
+	format         format
+	url            *template.Template
+	assetTemplates []*template.Template
+	versionRegexp  *regexp.Regexp
+}
+
+func New(spec Spec) (*Provider, error) {
+	if spec.Prefix == "" || strings.ContainsAny(spec.Prefix, ":@<>=") {
+		return nil, fmt.Errorf("Invalid provider prefix %q", spec.Prefix)
+	}
+	if spec.Name == "" {
+		spec.Name = spec.Prefix
+	}
+	if spec.Format == "" {
+		spec.Format = "json"
+	}
+	if spec.DateLayout == "" {
+		spec.DateLayout = time.RFC3339
+	}
+
+	p := &Provider{Spec: spec}
+
+	var ok bool
+	if p.format, ok = formats[spec.Format]; !ok {
+		return nil, fmt.Errorf("Unknown format %q of provider %q", spec.Format, spec.Name)
+	}
+	if spec.URL == "" || spec.Releases == "" || spec.Version == "" {
+		return nil, fmt.Errorf("Provider %q requires url, releases and version", spec.Name)
+	}
+
+	var err error
+	if p.url, err = template.New("url").Option("missingkey=error").Parse(spec.URL); err != nil {
+		return nil, fmt.Errorf("Failed to parse url of provider %q: %w", spec.Name, err)
+	}
+	for _, s := range spec.AssetTemplates {
+		t, err := template.New("asset").Option("missingkey=error").Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse asset template of provider %q: %w", spec.Name, err)
+		}
+		p.assetTemplates = append(p.assetTemplates, t)
+	}
+	if spec.VersionRegexp != "" {
+		if p.versionRegexp, err = regexp.Compile(spec.VersionRegexp); err != nil {
+			return nil, fmt.Errorf("Failed to parse version_regexp of provider %q: %w", spec.Name, err)
+		}
+	}
+	if spec.Format == "html" {
+		if _, err := regexp.Compile(spec.Releases); err != nil {
+			return nil, fmt.Errorf("Failed to parse releases regexp of provider %q: %w", spec.Name, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *Provider) Match(softwareId string) bool {
+	return strings.HasPrefix(softwareId, p.Spec.Prefix+":")
+}
+
+type templateData struct {
+	SoftwareId string
+	Name       string
+	Args       []string
+	Version    string
+}
+
+func (p *Provider) dataOf(softwareId string) templateData {
+	name := strings.TrimPrefix(softwareId, p.Spec.Prefix+":")
+	return templateData{
+		SoftwareId: softwareId,
+		Name:       name,
+		Args:       strings.Split(name, ":"),
+	}
+}
+
+func execute(t *template.Template, data templateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (p *Provider) selectTexts(n interface{}, expr string) ([]string, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	ns, err := p.format.selectNodes(n, expr)
+	if err != nil {
+		return nil, err
+	}
+	ss := make([]string, 0, len(ns))
+	for _, n := range ns {
+		if s := p.format.text(n); s != "" {
+			ss = append(ss, s)
+		}
+	}
+	return ss, nil
+}
+
+func (p *Provider) selectText(n interface{}, expr string) (string, error) {
+	ss, err := p.selectTexts(n, expr)
+	if err != nil || len(ss) == 0 {
+		return "", err
+	}
+	return ss[0], nil
+}
+
+func isTruthy(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "yes", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+// Parse extracts the releases of softwareId from the response bs fetched
+// from baseURL, against which relative asset URLs are resolved.
+func (p *Provider) Parse(bs []byte, baseURL, softwareId string) (releases.Releases, error) {
+	l := zap.S()
+
+	data := p.dataOf(softwareId)
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse url %q: %w", baseURL, err)
+	}
+
+	doc, err := p.format.parse(bs)
+	if err != nil {
+		return nil, err
+	}
+	rns, err := p.format.selectNodes(doc, p.Spec.Releases)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make(releases.Releases, 0, len(rns))
+	for _, rn := range rns {
+		originalName, err := p.selectText(rn, p.Spec.Version)
+		if err != nil {
+			return nil, err
+		}
+		verStr := originalName
+		if p.versionRegexp != nil {
+			ms := p.versionRegexp.FindStringSubmatch(verStr)
+			if len(ms) < 2 {
+				l.Debugf("Skipping version %q not matching %s", verStr, p.versionRegexp)
+				continue
+			}
+			verStr = ms[1]
+		}
+		ver, err := parser.ParseVersion(verStr)
+		if err != nil {
+			l.Debugf("Failed to parse version %q: %v", verStr, err)
+		}
+
+		r := releases.Release{
+			OriginalName: originalName,
+			Version:      ver,
+			Components:   parser.ParseComponents(verStr),
+			Unparsed:     err != nil,
+			Prerelease:   len(ver.Pre) > 0,
+		}
+		if verStr != originalName {
+			r.VersionString = verStr
+		}
+
+		prerelease, err := p.selectText(rn, p.Spec.Prerelease)
+		if err != nil {
+			return nil, err
+		}
+		r.Prerelease = r.Prerelease || isTruthy(prerelease)
+
+		dateStr, err := p.selectText(rn, p.Spec.Date)
+		if err != nil {
+			return nil, err
+		}
+		if dateStr != "" {
+			if publishedAt, err := time.Parse(p.Spec.DateLayout, dateStr); err == nil {
+				r.PublishedAt = publishedAt
+			} else {
+				l.Debugf("Failed to parse date %q of %s: %v", dateStr, verStr, err)
+			}
+		}
+
+		assetURLs, err := p.selectTexts(rn, p.Spec.Assets)
+		if err != nil {
+			return nil, err
+		}
+		data.Version = verStr
+		for _, t := range p.assetTemplates {
+			u, err := execute(t, data)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to execute asset template of provider %q: %w", p.Spec.Name, err)
+			}
+			assetURLs = append(assetURLs, u)
+		}
+		for _, s := range assetURLs {
+			u, err := url.Parse(s)
+			if err != nil {
+				l.Debugf("Skipping malformed asset url %q: %v", s, err)
+				continue
+			}
+			r.Assets = append(r.Assets, releases.NewAsset(base.ResolveReference(u).String()))
+		}
+
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func (p *Provider) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	if !p.Match(softwareId) {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: p.Spec.Name,
+			Err:         nil,
+		}
+	}
+	u, err := execute(p.url, p.dataOf(softwareId))
+	if err != nil {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: p.Spec.Name,
+			Err:         err,
+		}
+	}
+
+	bs, err := httpcli.Get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := p.Parse(bs, u, softwareId)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.Sort()
+
+	return rs, nil
+}
@@ -0,0 +1,189 @@
+package declarative_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/declarative"
+)
+
+const goJson = `[
+  {"version": "go1.22.1", "stable": true, "files": [
+    {"filename": "go1.22.1.linux-amd64.tar.gz", "kind": "archive"},
+    {"filename": "go1.22.1.src.tar.gz", "kind": "source"}]},
+  {"version": "go1.23rc1", "stable": false, "files": []},
+  {"version": "go1.21.8", "stable": true, "files": []}
+]`
+
+const mavenXml = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>org.example</groupId>
+  <versioning>
+    <versions>
+      <version>1.0.0</version>
+      <version>1.1.0</version>
+      <version> 2.0.0-M1 </version>
+    </versions>
+  </versioning>
+</metadata>`
+
+const downloadsHtml = `<ul>
+<li><a href="/dl/tool-3.1.0.tar.gz">tool 3.1.0</a> (2024-02-01)</li>
+<li><a href="/dl/tool-3.0.2.tar.gz">tool 3.0.2</a> (2023-12-24)</li>
+</ul>`
+
+type release struct {
+	Name        string
+	Prerelease  bool
+	PublishedAt string
+	Assets      []string
+}
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		name       string
+		spec       declarative.Spec
+		body       string
+		softwareId string
+		expected   []release
+	}{
+		{
+			name: "json",
+			spec: declarative.Spec{
+				Prefix:        "golang",
+				URL:           "https://go.dev/dl/?mode=json",
+				Releases:      "$[*]",
+				Version:       ".version",
+				VersionRegexp: `^go(.*)$`,
+				Assets:        ".files[*].filename",
+			},
+			// "1.23rc1" fails to parse and is left to the version scheme.
+			body:       goJson,
+			softwareId: "golang:go",
+			expected: []release{
+				{"go1.22.1", false, "", []string{"https://go.dev/dl/go1.22.1.linux-amd64.tar.gz", "https://go.dev/dl/go1.22.1.src.tar.gz"}},
+				{"go1.23rc1", false, "", nil},
+				{"go1.21.8", false, "", nil},
+			},
+		},
+		{
+			name: "xml",
+			spec: declarative.Spec{
+				Prefix:         "nexus",
+				URL:            "https://nexus.example/{{index .Args 0}}/maven-metadata.xml",
+				Format:         "xml",
+				Releases:       "//versioning/versions/version",
+				Version:        "text()",
+				AssetTemplates: []string{"https://nexus.example/{{index .Args 0}}/{{.Version}}/{{index .Args 1}}-{{.Version}}.jar"},
+			},
+			body:       mavenXml,
+			softwareId: "nexus:org/example:lib",
+			expected: []release{
+				{"1.0.0", false, "", []string{"https://nexus.example/org/example/1.0.0/lib-1.0.0.jar"}},
+				{"1.1.0", false, "", []string{"https://nexus.example/org/example/1.1.0/lib-1.1.0.jar"}},
+				{"2.0.0-M1", true, "", []string{"https://nexus.example/org/example/2.0.0-M1/lib-2.0.0-M1.jar"}},
+			},
+		},
+		{
+			name: "html",
+			spec: declarative.Spec{
+				Prefix:     "tool",
+				URL:        "https://tool.example/downloads/",
+				Format:     "html",
+				Releases:   `href="(?P<url>[^"]+)">tool (?P<version>[\d.]+)</a> \((?P<date>[\d-]+)\)`,
+				Version:    "version",
+				Date:       "date",
+				DateLayout: "2006-01-02",
+				Assets:     "url",
+			},
+			body:       downloadsHtml,
+			softwareId: "tool:",
+			expected: []release{
+				{"3.1.0", false, "2024-02-01", []string{"https://tool.example/dl/tool-3.1.0.tar.gz"}},
+				{"3.0.2", false, "2023-12-24", []string{"https://tool.example/dl/tool-3.0.2.tar.gz"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := declarative.New(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rs, err := p.Parse([]byte(tc.body), tc.spec.URL, tc.softwareId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []release
+			for _, r := range rs {
+				a := release{Name: r.OriginalName, Prerelease: r.Prerelease, Assets: r.AssetURLs()}
+				if !r.PublishedAt.IsZero() {
+					a.PublishedAt = r.PublishedAt.Format("2006-01-02")
+				}
+				actual = append(actual, a)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected releases (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/widget.json", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"items": {"a": {"v": "1.0.0", "at": "2024-01-02T03:04:05Z"}, "b": {"v": "1.2.0", "beta": "yes"}}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p, err := declarative.New(declarative.Spec{
+		Prefix:     "acme",
+		URL:        srv.URL + "/acme/{{.Name}}.json",
+		Releases:   ".items.*",
+		Version:    ".v",
+		Prerelease: ".beta",
+		Date:       ".at",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := p.Fetch(context.Background(), "acme:widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0].OriginalName != "1.2.0" || !rs[0].Prerelease || rs[1].Prerelease {
+		t.Fatalf("Unexpected releases: %+v", rs)
+	}
+	if expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !rs[1].PublishedAt.Equal(expected) {
+		t.Errorf("Unexpected PublishedAt: %v", rs[1].PublishedAt)
+	}
+
+	if _, err := p.Fetch(context.Background(), "other:widget"); err == nil {
+		t.Errorf("Expected an error for a softwareId of another prefix")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, spec := range []declarative.Spec{
+		{URL: "https://example.com", Releases: "$", Version: "."},
+		{Prefix: "a:b", URL: "https://example.com", Releases: "$", Version: "."},
+		{Prefix: "a", Releases: "$", Version: "."},
+		{Prefix: "a", URL: "https://example.com", Releases: "$", Version: ".", Format: "yaml"},
+		{Prefix: "a", URL: "https://example.com/{{", Releases: "$", Version: "."},
+		{Prefix: "a", URL: "https://example.com", Releases: "(", Version: "v", Format: "html"},
+	} {
+		if _, err := declarative.New(spec); err == nil {
+			t.Errorf("New(%+v): expected error", spec)
+		}
+	}
+}
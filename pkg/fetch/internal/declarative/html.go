@@ -0,0 +1,49 @@
+package declarative
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// htmlFormat scrapes pages with regexps. Each match of the releases regexp
+// is a release, and the other selectors name its capture groups.
+type htmlFormat struct{}
+
+func (htmlFormat) parse(bs []byte) (interface{}, error) {
+	return string(bs), nil
+}
+
+func (htmlFormat) selectNodes(n interface{}, expr string) ([]interface{}, error) {
+	switch v := n.(type) {
+	case string:
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse regexp %q: %w", expr, err)
+		}
+
+		var ns []interface{}
+		for _, ms := range re.FindAllStringSubmatch(v, -1) {
+			groups := make(map[string]string)
+			for i, name := range re.SubexpNames() {
+				if name != "" {
+					groups[name] = ms[i]
+				}
+			}
+			ns = append(ns, groups)
+		}
+		return ns, nil
+	case map[string]string:
+		g, ok := v[expr]
+		if !ok {
+			return nil, fmt.Errorf("No capture group named %q", expr)
+		}
+		return []interface{}{g}, nil
+	default:
+		return nil, fmt.Errorf("Unexpected node %T", n)
+	}
+}
+
+func (htmlFormat) text(n interface{}) string {
+	s, _ := n.(string)
+	return s
+}
@@ -0,0 +1,126 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type jsonStep struct {
+	key      string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+var reJsonStep = regexp.MustCompile(`^(?:\.?([^.\[\]]+)|\[(\*|\d*|"[^"]*")\])`)
+
+// parseJsonPath parses the subset of JSONPath and jq paths used to select
+// values: "$.releases[*].version", ".releases[].version", "files[0].url",
+// "*" and `["key with.dots"]`.
+func parseJsonPath(expr string) ([]jsonStep, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	if s == "." {
+		return nil, nil
+	}
+
+	var steps []jsonStep
+	for s != "" {
+		// ".[]" and "$.[0]"
+		if strings.HasPrefix(s, ".[") {
+			s = s[1:]
+		}
+		ms := reJsonStep.FindStringSubmatch(s)
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("Failed to parse JSON path %q at %q", expr, s)
+		}
+		s = s[len(ms[0]):]
+
+		switch {
+		case ms[1] == "*", ms[2] == "*", ms[0] == "[]":
+			steps = append(steps, jsonStep{wildcard: true})
+		case ms[1] != "":
+			steps = append(steps, jsonStep{key: ms[1]})
+		case strings.HasPrefix(ms[2], `"`):
+			steps = append(steps, jsonStep{key: strings.Trim(ms[2], `"`)})
+		default:
+			i, err := strconv.Atoi(ms[2])
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse JSON path %q: %w", expr, err)
+			}
+			steps = append(steps, jsonStep{index: i, isIndex: true})
+		}
+	}
+	return steps, nil
+}
+
+type jsonFormat struct{}
+
+func (jsonFormat) parse(bs []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("Failed to parse JSON: %w", err)
+	}
+	return v, nil
+}
+
+func (jsonFormat) selectNodes(n interface{}, expr string) ([]interface{}, error) {
+	steps, err := parseJsonPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := []interface{}{n}
+	for _, step := range steps {
+		var next []interface{}
+		for _, n := range ns {
+			switch v := n.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				} else if c, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, c)
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, v...)
+				case step.isIndex && step.index < len(v):
+					next = append(next, v[step.index])
+				}
+			}
+		}
+		ns = next
+	}
+	return ns, nil
+}
+
+func (jsonFormat) text(n interface{}) string {
+	switch v := n.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		bs, _ := json.Marshal(v)
+		return string(bs)
+	}
+}
@@ -0,0 +1,182 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     strings.Builder
+	parent   *xmlNode
+}
+
+// innerText is the concatenated text of the node and its descendants.
+func (n *xmlNode) innerText() string {
+	var sb strings.Builder
+	sb.WriteString(n.text.String())
+	for _, c := range n.children {
+		sb.WriteString(c.innerText())
+	}
+	return sb.String()
+}
+
+func (n *xmlNode) descendantsOrSelf() []*xmlNode {
+	ns := []*xmlNode{n}
+	for _, c := range n.children {
+		ns = append(ns, c.descendantsOrSelf()...)
+	}
+	return ns
+}
+
+type xmlFormat struct{}
+
+// parse returns the document node, whose only child is the root element.
+func (xmlFormat) parse(bs []byte) (interface{}, error) {
+	doc := &xmlNode{}
+	cur := doc
+
+	dec := xml.NewDecoder(bytes.NewReader(bs))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: make(map[string]string), parent: cur}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			cur.children = append(cur.children, n)
+			cur = n
+		case xml.EndElement:
+			if cur.parent != nil {
+				cur = cur.parent
+			}
+		case xml.CharData:
+			cur.text.Write(t)
+		}
+	}
+	return doc, nil
+}
+
+var reXPathStep = regexp.MustCompile(`^([^\[\]]+)(?:\[(\d+)\])?$`)
+
+// selectNodes evaluates the subset of XPath made of "/" and "//" separated
+// element names, "*", ".", "..", a trailing "@attr" or "text()", and 1-based
+// "[N]" positions. Paths starting with "/" are evaluated from the document.
+func (xmlFormat) selectNodes(n interface{}, expr string) ([]interface{}, error) {
+	cur, ok := n.(*xmlNode)
+	if !ok {
+		return nil, fmt.Errorf("Failed to evaluate XPath %q on an attribute", expr)
+	}
+
+	s := strings.TrimSpace(expr)
+	if strings.HasPrefix(s, "/") {
+		for cur.parent != nil {
+			cur = cur.parent
+		}
+		s = s[1:]
+	}
+
+	ns := []*xmlNode{cur}
+	descendant := false
+	parts := strings.Split(s, "/")
+	for i, part := range parts {
+		if part == "" {
+			descendant = true
+			continue
+		}
+		if descendant {
+			var all []*xmlNode
+			for _, n := range ns {
+				all = append(all, n.descendantsOrSelf()...)
+			}
+			ns = all
+			descendant = false
+		}
+
+		last := i == len(parts)-1
+		switch {
+		case part == ".":
+			continue
+		case part == "..":
+			var next []*xmlNode
+			for _, n := range ns {
+				if n.parent != nil {
+					next = append(next, n.parent)
+				}
+			}
+			ns = next
+			continue
+		case last && part == "text()":
+			vs := make([]interface{}, 0, len(ns))
+			for _, n := range ns {
+				vs = append(vs, n.text.String())
+			}
+			return vs, nil
+		case last && strings.HasPrefix(part, "@"):
+			var vs []interface{}
+			for _, n := range ns {
+				if v, ok := n.attrs[part[1:]]; ok {
+					vs = append(vs, v)
+				}
+			}
+			return vs, nil
+		}
+
+		ms := reXPathStep.FindStringSubmatch(part)
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("Failed to parse XPath %q at %q", expr, part)
+		}
+		name, pos := ms[1], 0
+		if ms[2] != "" {
+			pos, _ = strconv.Atoi(ms[2])
+		}
+
+		var next []*xmlNode
+		for _, n := range ns {
+			k := 0
+			for _, c := range n.children {
+				if name != "*" && c.name != name {
+					continue
+				}
+				k++
+				if pos == 0 || k == pos {
+					next = append(next, c)
+				}
+			}
+		}
+		ns = next
+	}
+
+	vs := make([]interface{}, 0, len(ns))
+	for _, n := range ns {
+		vs = append(vs, n)
+	}
+	return vs, nil
+}
+
+func (xmlFormat) text(n interface{}) string {
+	switch v := n.(type) {
+	case *xmlNode:
+		return strings.TrimSpace(v.innerText())
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,286 @@
+package firefox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const HandlerName = "firefox"
+
+// https://wiki.mozilla.org/Release_Management/Product_details
+const (
+	releasesEndpoint = "https://product-details.mozilla.org/1.0/firefox.json"
+	versionsEndpoint = "https://product-details.mozilla.org/1.0/firefox_versions.json"
+)
+
+const archiveRoot = "https://archive.mozilla.org/pub"
+
+// Channels maps a channel name to the release categories listed in
+// firefox.json. Channels without categories only have their latest version
+// published in firefox_versions.json, keyed by versionKeys.
+var Channels = map[string][]string{
+	"release":    {"major", "stability"},
+	"esr":        {"esr"},
+	"beta":       {"dev"},
+	"devedition": nil,
+	"nightly":    nil,
+}
+
+var versionKeys = map[string]string{
+	"devedition": "FIREFOX_DEVEDITION",
+	"nightly":    "FIREFOX_NIGHTLY",
+}
+
+var reFirefox = regexp.MustCompile(`^[Ff]irefox(:(\w+))?$`)
+
+func parseChannel(softwareId string) (string, bool) {
+	ms := reFirefox.FindStringSubmatch(softwareId)
+	if len(ms) == 0 {
+		return "", false
+	}
+
+	channel := strings.ToLower(ms[2])
+	if channel == "" {
+		channel = "release"
+	}
+	if _, ok := Channels[channel]; !ok {
+		return "", false
+	}
+	return channel, true
+}
+
+func Match(softwareId string) bool {
+	_, ok := parseChannel(softwareId)
+	return ok
+}
+
+var reFirefoxVersion = regexp.MustCompile(`^(\d+)\.(\d+)(\.(\d+))?(([ab])(\d+)|esr)?$`)
+
+var preKinds = map[string]string{
+	"a": "alpha",
+	"b": "beta",
+}
+
+// ParseVersion parses Firefox version strings such as "122.0", "115.7.0esr",
+// "123.0b3" and "124.0a1".
+func ParseVersion(s string) (semver.Version, error) {
+	ms := reFirefoxVersion.FindStringSubmatch(s)
+	if len(ms) == 0 {
+		return semver.Version{}, fmt.Errorf("Failed to parse Firefox version %q", s)
+	}
+
+	verStr := fmt.Sprintf("%s.%s", ms[1], ms[2])
+	if ms[4] != "" {
+		verStr += "." + ms[4]
+	} else {
+		verStr += ".0"
+	}
+	if ms[6] != "" {
+		verStr += fmt.Sprintf("-%s.%s", preKinds[ms[6]], ms[7])
+	}
+
+	ver, err := semver.Parse(verStr)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("Failed to parse Firefox version %q: %w", s, err)
+	}
+	return ver, nil
+}
+
+var platforms = []string{
+	"linux-x86_64",
+	"linux-i686",
+	"linux-aarch64",
+	"mac",
+	"win64",
+	"win64-aarch64",
+	"win32",
+}
+
+func filenameOf(platform, versionStr string, major uint64) string {
+	switch {
+	case strings.HasPrefix(platform, "linux"):
+		// Linux builds are xz compressed since Firefox 135.
+		if major >= 135 {
+			return fmt.Sprintf("firefox-%s.tar.xz", versionStr)
+		}
+		return fmt.Sprintf("firefox-%s.tar.bz2", versionStr)
+	case platform == "mac":
+		return fmt.Sprintf("Firefox%%20%s.dmg", versionStr)
+	default:
+		return fmt.Sprintf("Firefox%%20Setup%%20%s.exe", versionStr)
+	}
+}
+
+func nightlyFilenameOf(platform, versionStr string, major uint64) string {
+	switch {
+	case strings.HasPrefix(platform, "linux"):
+		if major >= 135 {
+			return fmt.Sprintf("firefox-%s.en-US.%s.tar.xz", versionStr, platform)
+		}
+		return fmt.Sprintf("firefox-%s.en-US.%s.tar.bz2", versionStr, platform)
+	case platform == "mac":
+		return fmt.Sprintf("firefox-%s.en-US.mac.dmg", versionStr)
+	default:
+		return fmt.Sprintf("firefox-%s.en-US.%s.installer.exe", versionStr, platform)
+	}
+}
+
+func assetURLsOf(channel, versionStr string, ver semver.Version) []string {
+	assetURLs := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		filename := filenameOf(p, versionStr, ver.Major)
+
+		var u string
+		switch channel {
+		case "nightly":
+			u = fmt.Sprintf("%s/firefox/nightly/latest-mozilla-central/%s", archiveRoot, nightlyFilenameOf(p, versionStr, ver.Major))
+		case "devedition":
+			u = fmt.Sprintf("%s/devedition/releases/%s/%s/en-US/%s", archiveRoot, versionStr, p, filename)
+		default:
+			u = fmt.Sprintf("%s/firefox/releases/%s/%s/en-US/%s", archiveRoot, versionStr, p, filename)
+		}
+		assetURLs = append(assetURLs, u)
+	}
+	return assetURLs
+}
+
+// releaseNotesURLOf returns the URL of the release notes, or "" for nightly
+// builds which have none.
+func releaseNotesURLOf(channel, versionStr string) string {
+	switch channel {
+	case "nightly":
+		return ""
+	case "beta", "devedition":
+		// "124.0b3" -> "124.0beta"
+		if ms := reFirefoxVersion.FindStringSubmatch(versionStr); len(ms) != 0 {
+			return fmt.Sprintf("https://www.mozilla.org/firefox/%s.%sbeta/releasenotes/", ms[1], ms[2])
+		}
+		return ""
+	default:
+		return fmt.Sprintf("https://www.mozilla.org/firefox/%s/releasenotes/", strings.TrimSuffix(versionStr, "esr"))
+	}
+}
+
+// ParseReleases parses firefox.json and returns releases in the specified channel.
+func ParseReleases(jsonbs []byte, channel string) (releases.Releases, error) {
+	l := zap.S()
+
+	type RawRelease struct {
+		Category string `json:"category"`
+		Version  string `json:"version"`
+		// e.g. "2024-01-23"
+		Date string `json:"date"`
+	}
+	type Response struct {
+		Releases map[string]RawRelease `json:"releases"`
+	}
+
+	var resp Response
+	if err := json.Unmarshal(jsonbs, &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	categories := make(map[string]struct{})
+	for _, c := range Channels[channel] {
+		categories[c] = struct{}{}
+	}
+
+	rs := make(releases.Releases, 0)
+	for _, rawr := range resp.Releases {
+		if _, ok := categories[rawr.Category]; !ok {
+			continue
+		}
+
+		ver, err := ParseVersion(rawr.Version)
+		if err != nil {
+			l.Debugf("%v", err)
+			continue
+		}
+
+		r := releases.Release{
+			OriginalName: rawr.Version,
+			Version:      ver,
+			Prerelease:   false,
+			Assets:       releases.NewAssets(assetURLsOf(channel, rawr.Version, ver)),
+		}
+		if publishedAt, err := time.Parse("2006-01-02", rawr.Date); err == nil {
+			r.PublishedAt = publishedAt
+		}
+		r.ChangelogURL = releaseNotesURLOf(channel, rawr.Version)
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// ParseVersions parses firefox_versions.json and returns the latest release
+// in the specified channel.
+func ParseVersions(jsonbs []byte, channel string) (releases.Releases, error) {
+	var db map[string]string
+	if err := json.Unmarshal(jsonbs, &db); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	versionStr, ok := db[versionKeys[channel]]
+	if !ok || versionStr == "" {
+		return nil, fmt.Errorf("Failed to find the latest version of Firefox %s", channel)
+	}
+
+	ver, err := ParseVersion(versionStr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := releases.Release{
+		OriginalName: versionStr,
+		Version:      ver,
+		Prerelease:   false,
+		Assets:       releases.NewAssets(assetURLsOf(channel, versionStr, ver)),
+		ChangelogURL: releaseNotesURLOf(channel, versionStr),
+	}
+	return releases.Releases{r}, nil
+}
+
+func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	channel, ok := parseChannel(softwareId)
+	if !ok {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: HandlerName,
+			Err:         nil,
+		}
+	}
+
+	var rs releases.Releases
+	if len(Channels[channel]) > 0 {
+		bs, err := httpcli.Get(ctx, releasesEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		if rs, err = ParseReleases(bs, channel); err != nil {
+			return nil, err
+		}
+	} else {
+		bs, err := httpcli.Get(ctx, versionsEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		if rs, err = ParseVersions(bs, channel); err != nil {
+			return nil, err
+		}
+	}
+
+	rs.Sort()
+
+	return rs, nil
+}
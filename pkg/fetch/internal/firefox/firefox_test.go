@@ -0,0 +1,38 @@
+package firefox_test
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/firefox"
+)
+
+func TestParseVersion(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{"122.0", "122.0.0"},
+		{"121.0.1", "121.0.1"},
+		{"115.7.0esr", "115.7.0"},
+		{"123.0b3", "123.0.0-beta.3"},
+		{"124.0a1", "124.0.0-alpha.1"},
+	}
+
+	for _, tc := range testcases {
+		actual, err := firefox.ParseVersion(tc.input)
+		if err != nil {
+			t.Errorf("ParseVersion(%q) failed: %v", tc.input, err)
+			continue
+		}
+
+		if !actual.Equals(semver.MustParse(tc.expected)) {
+			t.Errorf("ParseVersion(%q) expected %s actual %s", tc.input, tc.expected, actual)
+		}
+	}
+
+	if !semver.MustParse("123.0.0-beta.10").GT(semver.MustParse("123.0.0-beta.9")) {
+		t.Errorf("beta.10 should be newer than beta.9")
+	}
+}
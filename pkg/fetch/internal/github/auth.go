@@ -0,0 +1,183 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+)
+
+// Auth provides the value of the Authorization header sent to the GitHub API.
+type Auth interface {
+	Authorization(ctx context.Context) (string, error)
+}
+
+// TokenAuth authenticates with a personal access token or any other static
+// token, such as the one provided to GitHub Actions.
+type TokenAuth string
+
+func (t TokenAuth) Authorization(ctx context.Context) (string, error) {
+	return "Bearer " + string(t), nil
+}
+
+// AppAuth authenticates as an installation of a GitHub App. Installation
+// access tokens are minted on demand and cached until shortly before they
+// expire.
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/authenticating-as-a-github-app-installation
+type AppAuth struct {
+	// APIBase is the API endpoint of the host the App is installed on.
+	APIBase        string
+	AppId          int64
+	InstallationId int64
+	PrivateKey     *rsa.PrivateKey
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func ParsePrivateKey(pembs []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pembs)
+	if block == nil {
+		return nil, errors.New("Failed to decode PEM block of the GitHub App private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	keyi, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the GitHub App private key: %w", err)
+	}
+	key, ok := keyi.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("The GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+func (a *AppAuth) jwt(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		// Allow for clock drift, as recommended by GitHub.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(a.AppId, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("Failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func (a *AppAuth) mintToken(ctx context.Context) error {
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.APIBase, a.InstallationId)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to construct http.Request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := httpcli.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to issue request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read body of %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Failed to create a GitHub App installation token: status %s: %s", resp.Status, strings.TrimSpace(string(bs)))
+	}
+
+	var tokenResp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(bs, &tokenResp); err != nil {
+		return fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	a.token = tokenResp.Token
+	a.expiresAt = tokenResp.ExpiresAt
+	return nil
+}
+
+func (a *AppAuth) Authorization(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == "" || time.Until(a.expiresAt) < 5*time.Minute {
+		if err := a.mintToken(ctx); err != nil {
+			return "", err
+		}
+	}
+	return "Bearer " + a.token, nil
+}
+
+// NewAuth constructs an Auth from the first of the following which is
+// specified: a GitHub App installation, a token, or a file containing a
+// token. It returns nil if none is specified.
+func NewAuth(apiBase, token, tokenFile string, appId, installationId int64, privateKeyFile string) (Auth, error) {
+	if appId != 0 || installationId != 0 || privateKeyFile != "" {
+		if appId == 0 || installationId == 0 || privateKeyFile == "" {
+			return nil, errors.New("GitHub App authentication requires all of the app id, installation id and private key.")
+		}
+
+		pembs, err := ioutil.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the GitHub App private key: %w", err)
+		}
+		key, err := ParsePrivateKey(pembs)
+		if err != nil {
+			return nil, err
+		}
+		return &AppAuth{APIBase: apiBase, AppId: appId, InstallationId: installationId, PrivateKey: key}, nil
+	}
+
+	if token != "" {
+		return TokenAuth(token), nil
+	}
+
+	if tokenFile != "" {
+		bs, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the GitHub token file: %w", err)
+		}
+		return TokenAuth(strings.TrimSpace(string(bs))), nil
+	}
+
+	return nil, nil
+}
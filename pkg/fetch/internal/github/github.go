@@ -1,14 +1,16 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"regexp"
-	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -22,6 +24,8 @@ import (
 	"github.com/IPA-CyberLab/latest/pkg/releases"
 )
 
+const HandlerName = "github"
+
 var apiResultTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 	Namespace: "latest",
 	Subsystem: "github",
@@ -36,116 +40,374 @@ var apiSecondsHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
 	Help: "Seconds took to process GitHub API call.",
 })
 
-func githubHttpGet(ctx context.Context, url string) ([]byte, error) {
+func githubHttpGet(ctx context.Context, host *Host, url string) ([]byte, http.Header, error) {
+	return githubHttpDo(ctx, host, "GET", url, nil)
+}
+
+func githubHttpDo(ctx context.Context, host *Host, method, url string, body []byte) ([]byte, http.Header, error) {
 	start := time.Now()
 	defer func() {
 		apiSecondsHistogram.Observe(time.Since(start).Seconds())
 	}()
 
 	l := zap.S()
-	l.Debugf("github API call: %v", url)
+	l.Debugf("github API call: %s %v", method, url)
 
 	hc := httpcli.HttpClient
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to construct http.Request: %w", err)
-	}
 
-	req.Header = http.Header{}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	// Retry once if the request was rejected by the rate limit, and the
+	// limiter allows to wait for it.
+	for attempt := 0; ; attempt++ {
+		if err := host.limiter.wait(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		var bodyr io.Reader
+		if body != nil {
+			bodyr = bytes.NewReader(body)
+		}
+		// The rate limiter handles 429s.
+		req, err := http.NewRequestWithContext(httpcli.WithoutRateLimitRetry(ctx), method, url, bodyr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to construct http.Request: %w", err)
+		}
 
-	resp, err := hc.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to issue request to %s: %w", url, err)
-	}
-	defer resp.Body.Close()
+		req.Header = http.Header{}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if host.Auth != nil {
+			authz, err := host.Auth.Authorization(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			req.Header.Set("Authorization", authz)
+		}
+
+		resp, err := hc.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to issue request to %s: %w", url, err)
+		}
+
+		apiResultTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+
+		rateLimited := host.limiter.observe(host.Name, resp)
+		if rateLimited && attempt == 0 {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("Github API returned status %s", resp.Status)
+		}
 
-	apiResultTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+		bs, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to read body of %s: %w", url, err)
+		}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Github API returned status %s", resp.Status)
+		return bs, resp.Header, nil
 	}
+}
 
-	bs, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to read body of %s: %w", url, err)
+// MaxPages bounds the number of pages (of 100 entries each) read from a
+// paginated GitHub API listing.
+var MaxPages = 10
+
+var reLinkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextLink extracts the URL of the next page from the Link header.
+// https://docs.github.com/en/rest/using-the-rest-api/using-pagination-in-the-rest-api
+func nextLink(h http.Header) string {
+	for _, link := range h.Values("Link") {
+		if ms := reLinkNext.FindStringSubmatch(link); len(ms) != 0 {
+			return ms[1]
+		}
 	}
+	return ""
+}
 
-	return bs, nil
+// getAllPages calls handlePage with the body of each page of the listing at
+// url, following the Link headers up to MaxPages pages.
+func getAllPages(ctx context.Context, host *Host, url string, handlePage func(bs []byte) error) error {
+	url = fmt.Sprintf("%s?per_page=100", url)
+	for page := 0; url != "" && page < MaxPages; page++ {
+		bs, h, err := githubHttpGet(ctx, host, url)
+		if err != nil {
+			return err
+		}
+		if err := handlePage(bs); err != nil {
+			return err
+		}
+		url = nextLink(h)
+	}
+	if url != "" {
+		zap.S().Debugf("Stopped reading at MaxPages=%d. Next page: %s", MaxPages, url)
+	}
+	return nil
 }
 
-var reGithub = regexp.MustCompile(`^github.com/([A-z0-9]+-?[A-z0-9]*)/([A-z0-9\-_]+)$`)
+// Owners are alphanumeric with single hyphens in between. Repository names
+// may also contain dots and underscores. Flags follow as ":tags" or
+// ":prefix=PREFIX".
+var reGithub = regexp.MustCompile(`^([A-Za-z0-9\.\-]+(:\d+)?)/([A-Za-z0-9]+(?:-[A-Za-z0-9]+)*)/([A-Za-z0-9\-_\.]+)((?::[^:]+)*)$`)
 
-func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
-	l := zap.S()
+type repoId struct {
+	host     *Host
+	owner    string
+	repo     string
+	tagsMode bool
+	// prefix restricts the releases to the tags starting with it, which is
+	// stripped before parsing the version. Monorepos tag the releases of
+	// each of their components as e.g. "cli/v1.2.3".
+	prefix string
+}
+
+// explicitPrefix routes "github:OWNER/REPO" to github.com.
+const explicitPrefix = "github:"
 
-	ms := reGithub.FindStringSubmatch(softwareId)
+func parseSoftwareId(softwareId string) (repoId, error) {
+	s := softwareId
+	if strings.HasPrefix(s, explicitPrefix) {
+		s = DefaultHostName + "/" + strings.TrimPrefix(s, explicitPrefix)
+	}
+	ms := reGithub.FindStringSubmatch(s)
 	if len(ms) == 0 {
-		return nil, ferrors.ErrSoftwareIdParseFailed{
+		return repoId{}, ferrors.ErrSoftwareIdParseFailed{
 			Input:       softwareId,
-			HandlerName: "github",
+			HandlerName: HandlerName,
 			Err:         nil,
 		}
 	}
+	host, ok := Hosts[ms[1]]
+	if !ok {
+		return repoId{}, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: HandlerName,
+			Err:         fmt.Errorf("unknown GitHub host %q", ms[1]),
+		}
+	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", ms[1], ms[2])
+	id := repoId{host: host, owner: ms[3], repo: ms[4]}
+	for _, flag := range strings.Split(strings.TrimPrefix(ms[5], ":"), ":") {
+		switch {
+		case flag == "":
+		case flag == "tags":
+			id.tagsMode = true
+		case strings.HasPrefix(flag, "prefix="):
+			id.prefix = strings.TrimPrefix(flag, "prefix=")
+		default:
+			return repoId{}, ferrors.ErrSoftwareIdParseFailed{
+				Input:       softwareId,
+				HandlerName: HandlerName,
+				Err:         fmt.Errorf("unknown flag %q", flag),
+			}
+		}
+	}
+	return id, nil
+}
 
-	bs, err := githubHttpGet(ctx, url)
+func Match(softwareId string) bool {
+	_, err := parseSoftwareId(softwareId)
+	return err == nil
+}
+
+func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	id, err := parseSoftwareId(softwareId)
 	if err != nil {
 		return nil, err
 	}
+	host, owner, repo, tagsMode, prefix := id.host, id.owner, id.repo, id.tagsMode, id.prefix
 
+	var rs releases.Releases
+	if !tagsMode {
+		var err error
+		rs, err = fetchReleases(ctx, host, owner, repo, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if len(rs) == 0 {
+			zap.S().Debugf("%s/%s/%s has no releases. Falling back to tags.", host.Name, owner, repo)
+			tagsMode = true
+		}
+	}
+	if tagsMode {
+		var err error
+		rs, err = fetchTags(ctx, host, owner, repo, prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rs.Sort()
+	return rs, nil
+}
+
+// digestsOf maps the digest of an asset as the REST and GraphQL APIs return
+// it, e.g. "sha256:...", to Digests.
+func digestsOf(digest string) map[string]string {
+	if kv := strings.SplitN(digest, ":", 2); len(kv) == 2 {
+		return map[string]string{kv[0]: kv[1]}
+	}
+	return nil
+}
+
+// newRelease constructs a Release from the fields common to the REST and
+// GraphQL APIs. It returns false if the tag does not start with prefix. A
+// version which could not be parsed is left to the version scheme of the
+// query.
+func newRelease(prefix, name, tagName string, prerelease bool, body string, assets []releases.Asset) (releases.Release, bool) {
+	r := releases.Release{
+		Prerelease: prerelease,
+	}
+
+	if prefix != "" {
+		if !strings.HasPrefix(tagName, prefix) {
+			return releases.Release{}, false
+		}
+		r.OriginalName = tagName
+		r.VersionString = strings.TrimPrefix(tagName, prefix)
+		if ver, err := parser.ParseVersion(r.VersionString); err == nil {
+			r.Version = ver
+			r.Components = parser.ParseComponents(r.VersionString)
+		} else {
+			zap.S().Debugf("Failed to parse version from tagname %q", tagName)
+			r.Unparsed = true
+		}
+	} else if ver, err := parser.ParseVersion(tagName); err == nil {
+		r.OriginalName = tagName
+		r.Version = ver
+		r.Components = parser.ParseComponents(tagName)
+	} else if ver, err := parser.ParseVersion(name); err == nil {
+		r.OriginalName = name
+		r.Version = ver
+		r.Components = parser.ParseComponents(name)
+	} else {
+		zap.S().Debugf("Failed to parse version from release name %q tagname %q", name, tagName)
+		r.OriginalName = tagName
+		r.Unparsed = true
+	}
+	// l.Debugf("Parse version from release name %q tagname %q -> %v", tagName, name, r.Version)
+
+	r.Assets = append(releases.NewAssets(scrapeutil.ScrapeLinks(body)), assets...)
+	r.Notes = body
+	return r, true
+}
+
+func fetchReleases(ctx context.Context, host *Host, owner, repo, prefix string) (releases.Releases, error) {
 	type Assets struct {
+		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
+		ContentType        string `json:"content_type"`
+		DownloadCount      int64  `json:"download_count"`
+		// e.g. "sha256:..."
+		Digest string `json:"digest"`
 	}
 
 	type RawRelease struct {
-		Name       string   `json:"name"`
-		TagName    string   `json:"tag_name"`
-		Draft      bool     `json:"draft"`
-		Prerelease bool     `json:"prerelease"`
-		Assets     []Assets `json:"assets"`
-		Body       string   `json:"body"`
+		Name        string    `json:"name"`
+		TagName     string    `json:"tag_name"`
+		Draft       bool      `json:"draft"`
+		Prerelease  bool      `json:"prerelease"`
+		Assets      []Assets  `json:"assets"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+		HtmlURL     string    `json:"html_url"`
 	}
 
-	var rawrs []RawRelease
-	if err := json.Unmarshal(bs, &rawrs); err != nil {
-		return nil, fmt.Errorf("Failed to parse response: %w", err)
-	}
+	url := fmt.Sprintf("%s/repos/%s/%s/releases", host.APIBase, owner, repo)
 
-	rs := make(releases.Releases, 0, len(rawrs))
-	for _, rawr := range rawrs {
-		if rawr.Draft {
-			continue
+	rs := make(releases.Releases, 0)
+	err := getAllPages(ctx, host, url, func(bs []byte) error {
+		var rawrs []RawRelease
+		if err := json.Unmarshal(bs, &rawrs); err != nil {
+			return fmt.Errorf("Failed to parse response: %w", err)
 		}
 
-		r := releases.Release{
-			Prerelease: rawr.Prerelease,
-			AssetURLs:  make([]string, 0, len(rawr.Assets)),
-		}
+		for _, rawr := range rawrs {
+			if rawr.Draft {
+				continue
+			}
 
-		if ver, err := parser.ParseVersion(rawr.TagName); err == nil {
-			r.OriginalName = rawr.TagName
-			r.Version = ver
-		} else if ver, err := parser.ParseVersion(rawr.Name); err == nil {
-			r.OriginalName = rawr.Name
-			r.Version = ver
-		} else {
-			l.Warnf("Failed to parse version from release name %q tagname %q", rawr.TagName, rawr.Name)
-			continue
-		}
-		// l.Debugf("Parse version from release name %q tagname %q -> %v", rawr.TagName, rawr.Name, r.Version)
+			assets := make([]releases.Asset, 0, len(rawr.Assets))
+			for _, a := range rawr.Assets {
+				assets = append(assets, releases.Asset{
+					Name:          a.Name,
+					URL:           a.BrowserDownloadURL,
+					Size:          a.Size,
+					ContentType:   a.ContentType,
+					DownloadCount: a.DownloadCount,
+					Digests:       digestsOf(a.Digest),
+				})
+			}
 
-		r.AssetURLs = scrapeutil.ScrapeLinks(rawr.Body)
-		for _, a := range rawr.Assets {
-			r.AssetURLs = append(r.AssetURLs, a.BrowserDownloadURL)
+			r, ok := newRelease(prefix, rawr.Name, rawr.TagName, rawr.Prerelease, rawr.Body, assets)
+			if !ok {
+				continue
+			}
+			r.PublishedAt = rawr.PublishedAt
+			r.ChangelogURL = rawr.HtmlURL
+			rs = append(rs, r)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
 
-		rs = append(rs, r)
+func fetchTags(ctx context.Context, host *Host, owner, repo, prefix string) (releases.Releases, error) {
+	l := zap.S()
+
+	type RawTag struct {
+		Name string `json:"name"`
 	}
-	sort.SliceStable(rs, func(i, j int) bool {
-		return rs[i].Version.GT(rs[j].Version)
+
+	url := fmt.Sprintf("%s/repos/%s/%s/tags", host.APIBase, owner, repo)
+
+	rs := make(releases.Releases, 0)
+	err := getAllPages(ctx, host, url, func(bs []byte) error {
+		var rawts []RawTag
+		if err := json.Unmarshal(bs, &rawts); err != nil {
+			return fmt.Errorf("Failed to parse response: %w", err)
+		}
+
+		for _, rawt := range rawts {
+			if !strings.HasPrefix(rawt.Name, prefix) {
+				continue
+			}
+			verStr := strings.TrimPrefix(rawt.Name, prefix)
+			ver, err := parser.ParseVersion(verStr)
+			if err != nil {
+				l.Debugf("Failed to parse version from tag %q", rawt.Name)
+			}
+
+			r := releases.Release{
+				OriginalName: rawt.Name,
+				Version:      ver,
+				Components:   parser.ParseComponents(verStr),
+				Unparsed:     err != nil,
+				Prerelease:   len(ver.Pre) > 0,
+				Assets: releases.NewAssets([]string{
+					fmt.Sprintf("https://%s/%s/%s/archive/refs/tags/%s.tar.gz", host.Name, owner, repo, rawt.Name),
+					fmt.Sprintf("https://%s/%s/%s/archive/refs/tags/%s.zip", host.Name, owner, repo, rawt.Name),
+				}),
+			}
+			if prefix != "" {
+				r.VersionString = verStr
+			}
+			rs = append(rs, r)
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return rs, nil
 }
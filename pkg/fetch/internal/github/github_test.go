@@ -0,0 +1,317 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetAllPages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("per_page") != "100" {
+			t.Errorf("Expected per_page=100, got %q", req.URL.RawQuery)
+		}
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 5 {
+			w.Header().Set("Link", fmt.Sprintf(`<%[1]s/x?per_page=100&page=%[2]d>; rel="next", <%[1]s/x?per_page=100&page=5>; rel="last"`, srv.URL, page+1))
+		}
+		_, _ = fmt.Fprintf(w, "%d", page)
+	}))
+	defer srv.Close()
+
+	origMaxPages := MaxPages
+	defer func() { MaxPages = origMaxPages }()
+
+	for _, tc := range []struct {
+		maxPages int
+		expected string
+	}{
+		{10, "12345"},
+		{3, "123"},
+	} {
+		MaxPages = tc.maxPages
+
+		var got string
+		err := getAllPages(context.Background(), NewHost("test.example", srv.URL, nil), srv.URL+"/x", func(bs []byte) error {
+			got += string(bs)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("getAllPages failed: %v", err)
+		}
+		if got != tc.expected {
+			t.Errorf("MaxPages=%d: expected pages %q, got %q", tc.maxPages, tc.expected, got)
+		}
+	}
+}
+
+func TestParseSoftwareId(t *testing.T) {
+	testcases := []struct {
+		input    string
+		owner    string
+		repo     string
+		tagsMode bool
+		prefix   string
+	}{
+		{"github.com/foo/bar:tags", "foo", "bar", true, ""},
+		{"github.com/aws-ia/terraform-aws-eks-blueprints", "aws-ia", "terraform-aws-eks-blueprints", false, ""},
+		{"github.com/my-org-name/bar.js", "my-org-name", "bar.js", false, ""},
+		{"github.com/kubernetes-sigs/kustomize:prefix=kustomize/", "kubernetes-sigs", "kustomize", false, "kustomize/"},
+		{"github.com/aws/aws-sdk-go-v2:prefix=service/s3/:tags", "aws", "aws-sdk-go-v2", true, "service/s3/"},
+		{"github:foo/bar:tags", "foo", "bar", true, ""},
+	}
+	for _, tc := range testcases {
+		id, err := parseSoftwareId(tc.input)
+		if err != nil {
+			t.Errorf("parseSoftwareId(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if id.owner != tc.owner || id.repo != tc.repo || id.tagsMode != tc.tagsMode || id.prefix != tc.prefix {
+			t.Errorf("parseSoftwareId(%q) unexpected result: %+v", tc.input, id)
+		}
+	}
+
+	for _, input := range []string{
+		"github.com/-foo/bar",
+		"github.com/foo--bar/baz",
+		"github.com/foo/bar:unknown",
+	} {
+		if _, err := parseSoftwareId(input); err == nil {
+			t.Errorf("Expected parseSoftwareId(%q) to fail", input)
+		}
+	}
+}
+
+func TestFetchPrefix(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/repos/kubernetes-sigs/kustomize/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+		  {"tag_name": "api/v0.16.0"},
+		  {"tag_name": "kustomize/v5.3.0"},
+		  {"tag_name": "kyaml/v0.16.0"},
+		  {"tag_name": "kustomize/v5.2.1"}
+		]`))
+	}))
+	defer srv.Close()
+
+	Hosts["prefix.test"] = NewHost("prefix.test", srv.URL, nil)
+	defer delete(Hosts, "prefix.test")
+
+	rs, err := Fetch(context.Background(), "prefix.test/kubernetes-sigs/kustomize:prefix=kustomize/")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rs) != 2 || rs[0].OriginalName != "kustomize/v5.3.0" || rs[0].Version.String() != "5.3.0" {
+		t.Errorf("Unexpected releases: %+v", rs)
+	}
+}
+
+func TestFetchTagsPrefix(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/aws/aws-sdk-go-v2/releases":
+			// Releases not carrying the prefix.
+			_, _ = w.Write([]byte(`[{"tag_name": "release-2024-03-01"}]`))
+		case "/repos/aws/aws-sdk-go-v2/tags":
+			_, _ = w.Write([]byte(`[
+			  {"name": "v1.26.0"},
+			  {"name": "service/s3/v1.51.4"},
+			  {"name": "service/sqs/v1.31.3"},
+			  {"name": "service/s3/v1.51.3"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	Hosts["tags.test"] = NewHost("tags.test", srv.URL, nil)
+	defer delete(Hosts, "tags.test")
+
+	for _, softwareId := range []string{
+		"tags.test/aws/aws-sdk-go-v2:tags:prefix=service/s3/",
+		"tags.test/aws/aws-sdk-go-v2:prefix=service/s3/",
+	} {
+		rs, err := Fetch(context.Background(), softwareId)
+		if err != nil {
+			t.Fatalf("Fetch(%q) failed: %v", softwareId, err)
+		}
+		if len(rs) != 2 || rs[0].OriginalName != "service/s3/v1.51.4" || rs[0].Version.String() != "1.51.4" || rs[0].VersionString != "v1.51.4" {
+			t.Errorf("%s: Unexpected releases: %+v", softwareId, rs)
+		}
+	}
+}
+
+func TestParseHostSpec(t *testing.T) {
+	testcases := []struct {
+		spec    string
+		name    string
+		apiBase string
+	}{
+		{"ghe.corp.example", "ghe.corp.example", "https://ghe.corp.example/api/v3"},
+		{"ghe.corp.example=http://localhost:8080/api/v3", "ghe.corp.example", "http://localhost:8080/api/v3"},
+	}
+	for _, tc := range testcases {
+		name, apiBase, err := ParseHostSpec(tc.spec)
+		if err != nil {
+			t.Errorf("ParseHostSpec(%q) failed: %v", tc.spec, err)
+			continue
+		}
+		if name != tc.name || apiBase != tc.apiBase {
+			t.Errorf("ParseHostSpec(%q) expected (%q, %q), got (%q, %q)", tc.spec, tc.name, tc.apiBase, name, apiBase)
+		}
+	}
+
+	if _, _, err := ParseHostSpec("https://ghe.corp.example"); err == nil {
+		t.Errorf("Expected ParseHostSpec to fail on a URL")
+	}
+}
+
+func TestFetchEnterprise(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v3/repos/tools/deployer/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer ghe-t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"tag_name": "v1.2.0"}, {"tag_name": "v1.10.0", "published_at": "2024-03-01T12:00:00Z"}]`))
+	}))
+	defer srv.Close()
+
+	os.Setenv(TokenEnvName("ghe.test"), "ghe-t0ken")
+	defer os.Unsetenv(TokenEnvName("ghe.test"))
+	if err := RegisterEnterpriseHost("ghe.test=" + srv.URL + "/api/v3"); err != nil {
+		t.Fatalf("RegisterEnterpriseHost failed: %v", err)
+	}
+	defer delete(Hosts, "ghe.test")
+
+	rs, err := Fetch(context.Background(), "ghe.test/tools/deployer")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rs) != 2 || rs[0].OriginalName != "v1.10.0" || !rs[0].PublishedAt.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected releases: %+v", rs)
+	}
+
+	if _, err := Fetch(context.Background(), "unknown.example/tools/deployer"); err == nil {
+		t.Errorf("Expected Fetch to fail on an unknown host")
+	}
+}
+
+func TestFetchMany(t *testing.T) {
+	var graphqlCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/graphql":
+			graphqlCalls++
+			var body struct {
+				Variables map[string]string `json:"variables"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Errorf("Failed to decode GraphQL request: %v", err)
+			}
+			if body.Variables["o0"] != "tools" || body.Variables["n1"] != "empty" {
+				t.Errorf("Unexpected variables: %v", body.Variables)
+			}
+			_, _ = w.Write([]byte(`{
+			  "data": {
+			    "r0": {"releases": {"nodes": [
+			      {"tagName": "v1.2.0", "releaseAssets": {"nodes": [{"downloadUrl": "https://ghe.test/a.tar.gz", "digest": "sha256:0123abcd"}]}},
+			      {"tagName": "v1.10.0", "isPrerelease": true},
+			      {"tagName": "v2.0.0", "isDraft": true}
+			    ]}},
+			    "r1": {"releases": {"nodes": []}},
+			    "r2": null
+			  },
+			  "errors": [{"path": ["r2"], "message": "Could not resolve to a Repository"}]
+			}`))
+		case "/api/v3/repos/tools/empty/releases":
+			_, _ = w.Write([]byte(`[]`))
+		case "/api/v3/repos/tools/empty/tags":
+			_, _ = w.Write([]byte(`[{"name": "v0.1.0"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	Hosts["ghe.test"] = NewHost("ghe.test", srv.URL+"/api/v3", TokenAuth("t0ken"))
+	defer delete(Hosts, "ghe.test")
+
+	ids := []string{"ghe.test/tools/deployer", "ghe.test/tools/empty", "ghe.test/tools/missing"}
+	for _, id := range ids {
+		if !Batchable(id) {
+			t.Errorf("Expected %q to be batchable", id)
+		}
+	}
+	if Batchable("ghe.test/tools/deployer:tags") {
+		t.Errorf("Expected tags mode not to be batchable")
+	}
+
+	rss, errs := FetchMany(context.Background(), ids)
+	if graphqlCalls != 1 {
+		t.Errorf("Expected a single GraphQL call, got %d", graphqlCalls)
+	}
+	if rs := rss["ghe.test/tools/deployer"]; len(rs) != 2 || rs[0].OriginalName != "v1.10.0" || !rs[0].Prerelease || len(rs[1].Assets) != 1 || rs[1].Assets[0].Digests["sha256"] != "0123abcd" {
+		t.Errorf("Unexpected releases: %+v", rs)
+	}
+	if rs := rss["ghe.test/tools/empty"]; len(rs) != 1 || rs[0].OriginalName != "v0.1.0" {
+		t.Errorf("Expected fallback to tags, got %+v", rs)
+	}
+	if errs["ghe.test/tools/missing"] == nil {
+		t.Errorf("Expected an error for the missing repository")
+	}
+	if len(errs) != 1 {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
+
+func TestParseResponseHasNextPage(t *testing.T) {
+	origMaxPages := MaxPages
+	defer func() { MaxPages = origMaxPages }()
+
+	bs := []byte(`{"data": {
+  "r0": {"releases": {"nodes": [{"tagName": "v1.0.0"}], "pageInfo": {"hasNextPage": false}}},
+  "r1": {"releases": {"nodes": [{"tagName": "v2.0.0"}], "pageInfo": {"hasNextPage": true}}}
+}}`)
+
+	for _, tc := range []struct {
+		maxPages int
+		expected []int
+	}{
+		{10, []int{0}},
+		{1, []int{0, 1}},
+	} {
+		MaxPages = tc.maxPages
+
+		rss, errs, err := parseResponse(bs, 2)
+		if err != nil || len(errs) > 0 {
+			t.Fatalf("parseResponse failed: %v %v", err, errs)
+		}
+		var got []int
+		for i := 0; i < 2; i++ {
+			if len(rss[i]) > 0 {
+				got = append(got, i)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+			t.Errorf("MaxPages=%d: expected releases of %v, got %v", tc.maxPages, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// BatchSize is the maximum number of repositories queried in a single
+// GraphQL request.
+var BatchSize = 25
+
+// GraphQLURL returns the GraphQL API endpoint of the host.
+// https://docs.github.com/en/enterprise-server/graphql/guides/forming-calls-with-graphql#the-graphql-endpoint
+func (h *Host) GraphQLURL() string {
+	if strings.HasSuffix(h.APIBase, "/api/v3") {
+		return strings.TrimSuffix(h.APIBase, "/v3") + "/graphql"
+	}
+	return h.APIBase + "/graphql"
+}
+
+// Batchable reports if the softwareId may be fetched by FetchMany. The GraphQL
+// API is only available to authenticated clients, and only the releases (not
+// tags) are queried. Monorepos are not batched either, as the latest releases
+// may not include any of the component specified by the prefix.
+func Batchable(softwareId string) bool {
+	id, err := parseSoftwareId(softwareId)
+	if err != nil {
+		return false
+	}
+	return !id.tagsMode && id.prefix == "" && id.host.Auth != nil
+}
+
+const releasesFragment = `fragment releases on Repository {
+  releases(first: 100, orderBy: {field: CREATED_AT, direction: DESC}) {
+    nodes {
+      name
+      tagName
+      isDraft
+      isPrerelease
+      description
+      publishedAt
+      url
+      releaseAssets(first: 100) {
+        nodes {
+          name
+          downloadUrl
+          size
+          contentType
+          downloadCount
+          digest
+        }
+      }
+    }
+    pageInfo {
+      hasNextPage
+    }
+  }
+}`
+
+func buildQuery(ids []repoId) ([]byte, error) {
+	var params, fields []string
+	vars := make(map[string]string)
+	for i, id := range ids {
+		params = append(params, fmt.Sprintf("$o%[1]d: String!, $n%[1]d: String!", i))
+		fields = append(fields, fmt.Sprintf("  r%[1]d: repository(owner: $o%[1]d, name: $n%[1]d) { ...releases }", i))
+		vars[fmt.Sprintf("o%d", i)] = id.owner
+		vars[fmt.Sprintf("n%d", i)] = id.repo
+	}
+
+	query := fmt.Sprintf("query(%s) {\n%s\n}\n%s", strings.Join(params, ", "), strings.Join(fields, "\n"), releasesFragment)
+	return json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": vars,
+	})
+}
+
+type graphqlRelease struct {
+	Name          string    `json:"name"`
+	TagName       string    `json:"tagName"`
+	IsDraft       bool      `json:"isDraft"`
+	IsPrerelease  bool      `json:"isPrerelease"`
+	Description   string    `json:"description"`
+	PublishedAt   time.Time `json:"publishedAt"`
+	URL           string    `json:"url"`
+	ReleaseAssets struct {
+		Nodes []struct {
+			Name          string `json:"name"`
+			DownloadURL   string `json:"downloadUrl"`
+			Size          int64  `json:"size"`
+			ContentType   string `json:"contentType"`
+			DownloadCount int64  `json:"downloadCount"`
+			// e.g. "sha256:..."
+			Digest string `json:"digest"`
+		} `json:"nodes"`
+	} `json:"releaseAssets"`
+}
+
+type graphqlRepository struct {
+	Releases struct {
+		Nodes    []graphqlRelease `json:"nodes"`
+		PageInfo struct {
+			HasNextPage bool `json:"hasNextPage"`
+		} `json:"pageInfo"`
+	} `json:"releases"`
+}
+
+// parseResponse returns the releases and errors keyed by the index of the
+// repository in the batch. Repositories with more releases than the first
+// page are left out if Fetch would read more pages, so that they resolve
+// the same whether batched or not.
+func parseResponse(bs []byte, n int) (map[int]releases.Releases, map[int]error, error) {
+	var resp struct {
+		Data   map[string]*graphqlRepository `json:"data"`
+		Errors []struct {
+			Path    []interface{} `json:"path"`
+			Message string        `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(bs, &resp); err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	errs := make(map[int]error)
+	for _, e := range resp.Errors {
+		var i int
+		if len(e.Path) > 0 {
+			if alias, ok := e.Path[0].(string); ok {
+				if _, err := fmt.Sscanf(alias, "r%d", &i); err == nil {
+					errs[i] = fmt.Errorf("GitHub GraphQL API error: %s", e.Message)
+					continue
+				}
+			}
+		}
+		// An error not attributable to a repository fails the whole batch.
+		return nil, nil, fmt.Errorf("GitHub GraphQL API error: %s", e.Message)
+	}
+
+	rss := make(map[int]releases.Releases)
+	for i := 0; i < n; i++ {
+		if _, ok := errs[i]; ok {
+			continue
+		}
+		repo := resp.Data[fmt.Sprintf("r%d", i)]
+		if repo == nil {
+			errs[i] = fmt.Errorf("GitHub GraphQL API returned no data for the repository")
+			continue
+		}
+		if repo.Releases.PageInfo.HasNextPage && MaxPages > 1 {
+			continue
+		}
+
+		rs := make(releases.Releases, 0, len(repo.Releases.Nodes))
+		for _, rawr := range repo.Releases.Nodes {
+			if rawr.IsDraft {
+				continue
+			}
+
+			assets := make([]releases.Asset, 0, len(rawr.ReleaseAssets.Nodes))
+			for _, a := range rawr.ReleaseAssets.Nodes {
+				assets = append(assets, releases.Asset{
+					Name:          a.Name,
+					URL:           a.DownloadURL,
+					Size:          a.Size,
+					ContentType:   a.ContentType,
+					DownloadCount: a.DownloadCount,
+					Digests:       digestsOf(a.Digest),
+				})
+			}
+
+			r, ok := newRelease("", rawr.Name, rawr.TagName, rawr.IsPrerelease, rawr.Description, assets)
+			if !ok {
+				continue
+			}
+			r.PublishedAt = rawr.PublishedAt
+			r.ChangelogURL = rawr.URL
+			rs = append(rs, r)
+		}
+		rs.Sort()
+		rss[i] = rs
+	}
+	return rss, errs, nil
+}
+
+func fetchBatch(ctx context.Context, host *Host, ids []repoId) (map[int]releases.Releases, map[int]error, error) {
+	body, err := buildQuery(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bs, _, err := githubHttpDo(ctx, host, "POST", host.GraphQLURL(), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseResponse(bs, len(ids))
+}
+
+// FetchMany fetches the releases of many repositories with a GraphQL query
+// per BatchSize repositories on the same host, instead of a REST API call
+// per repository. All softwareIds must be Batchable.
+//
+// Only the latest 100 releases of each repository are queried. Repositories
+// with more releases, or without any release, are fetched through Fetch, so
+// that they are paginated or fall back to tags.
+func FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	l := zap.S()
+
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+
+	byHost := make(map[*Host][]string)
+	for _, softwareId := range softwareIds {
+		id, err := parseSoftwareId(softwareId)
+		if err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		byHost[id.host] = append(byHost[id.host], softwareId)
+	}
+
+	for host, hostIds := range byHost {
+		for start := 0; start < len(hostIds); start += BatchSize {
+			end := start + BatchSize
+			if end > len(hostIds) {
+				end = len(hostIds)
+			}
+			batch := hostIds[start:end]
+
+			ids := make([]repoId, 0, len(batch))
+			for _, softwareId := range batch {
+				id, _ := parseSoftwareId(softwareId)
+				ids = append(ids, id)
+			}
+
+			l.Debugf("Fetching %d repositories on %s in a GraphQL query", len(batch), host.Name)
+			batchRss, batchErrs, err := fetchBatch(ctx, host, ids)
+			for i, softwareId := range batch {
+				if err != nil {
+					errs[softwareId] = err
+				} else if e, ok := batchErrs[i]; ok {
+					errs[softwareId] = e
+				} else if len(batchRss[i]) == 0 {
+					rss[softwareId], errs[softwareId] = Fetch(ctx, softwareId)
+				} else {
+					rss[softwareId] = batchRss[i]
+				}
+			}
+		}
+	}
+
+	for softwareId, err := range errs {
+		if err == nil {
+			delete(errs, softwareId)
+		}
+	}
+	return rss, errs
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const DefaultHostName = "github.com"
+
+// Host is a GitHub instance, either github.com or a GitHub Enterprise Server.
+type Host struct {
+	// Name is the hostname used in softwareIds, e.g. "ghe.corp.example".
+	Name string
+	// APIBase is the REST API endpoint without the trailing slash,
+	// e.g. "https://ghe.corp.example/api/v3".
+	APIBase string
+	// Auth is used for the API calls to the host if non-nil. Requests are
+	// unauthenticated otherwise, which on github.com is subject to the rate
+	// limit of 60 requests per hour.
+	Auth Auth
+
+	limiter *rateLimiter
+}
+
+func NewHost(name, apiBase string, auth Auth) *Host {
+	return &Host{
+		Name:    name,
+		APIBase: strings.TrimRight(apiBase, "/"),
+		Auth:    auth,
+		limiter: &rateLimiter{},
+	}
+}
+
+// Hosts are the GitHub instances recognized in softwareIds, keyed by Name.
+var Hosts = map[string]*Host{
+	DefaultHostName: NewHost(DefaultHostName, "https://api.github.com", nil),
+}
+
+var reHostSpec = regexp.MustCompile(`^([A-Za-z0-9\.\-]+(:\d+)?)(=(https?://\S+))?$`)
+
+// ParseHostSpec parses "HOST" or "HOST=APIBASE". The API base defaults to
+// https://HOST/api/v3, as served by GitHub Enterprise Server.
+func ParseHostSpec(spec string) (string, string, error) {
+	ms := reHostSpec.FindStringSubmatch(spec)
+	if len(ms) == 0 {
+		return "", "", fmt.Errorf("Failed to parse GitHub host %q. Expected \"HOST\" or \"HOST=APIBASE\".", spec)
+	}
+
+	name, apiBase := ms[1], ms[4]
+	if apiBase == "" {
+		apiBase = fmt.Sprintf("https://%s/api/v3", name)
+	}
+	return name, apiBase, nil
+}
+
+var reNonWord = regexp.MustCompile(`\W`)
+
+// TokenEnvName returns the name of the environment variable holding the
+// token for the host, e.g. $LATEST_GITHUB_TOKEN_GHE_CORP_EXAMPLE.
+func TokenEnvName(hostName string) string {
+	return "LATEST_GITHUB_TOKEN_" + strings.ToUpper(reNonWord.ReplaceAllString(hostName, "_"))
+}
+
+// RegisterEnterpriseHost adds a GitHub Enterprise Server host specified as
+// in ParseHostSpec. Its token is read from the environment variable named
+// by TokenEnvName.
+func RegisterEnterpriseHost(spec string) error {
+	name, apiBase, err := ParseHostSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	var auth Auth
+	if token := os.Getenv(TokenEnvName(name)); token != "" {
+		auth = TokenAuth(token)
+	}
+	Hosts[name] = NewHost(name, apiBase, auth)
+	return nil
+}
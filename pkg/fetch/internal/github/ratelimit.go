@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var rateLimitLimitGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "latest",
+	Subsystem: "github",
+	Name:      "ratelimit_limit",
+	Help:      "The maximum number of GitHub API requests permitted per hour, as of the last response.",
+}, []string{"host"})
+var rateLimitRemainingGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "latest",
+	Subsystem: "github",
+	Name:      "ratelimit_remaining",
+	Help:      "The number of GitHub API requests remaining in the current rate limit window, as of the last response.",
+}, []string{"host"})
+var rateLimitResetGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "latest",
+	Subsystem: "github",
+	Name:      "ratelimit_reset_timestamp_seconds",
+	Help:      "The time at which the current GitHub API rate limit window resets, in unix epoch seconds.",
+}, []string{"host"})
+var rateLimitBackoffTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "latest",
+	Subsystem: "github",
+	Name:      "ratelimit_backoffs_total",
+	Help:      "Total number of GitHub API calls not issued because of the rate limit.",
+})
+
+// MaxRateLimitWait is the longest duration a GitHub API call waits for the
+// rate limit to reset. Calls which would need to wait longer fail with
+// ErrRateLimited without hitting the API.
+var MaxRateLimitWait = 30 * time.Second
+
+var NowImpl func() time.Time = time.Now
+
+type ErrRateLimited struct {
+	Until time.Time
+}
+
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("GitHub API rate limit exceeded. Backing off until %s", e.Until.Format(time.RFC3339))
+}
+
+type rateLimiter struct {
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+// wait blocks until the rate limit allows a new request, or returns
+// ErrRateLimited if that is further than MaxRateLimitWait away.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	rl.mu.Lock()
+	until := rl.blockedUntil
+	rl.mu.Unlock()
+
+	d := until.Sub(NowImpl())
+	if d <= 0 {
+		return nil
+	}
+	if d > MaxRateLimitWait {
+		rateLimitBackoffTotal.Inc()
+		return ErrRateLimited{Until: until}
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(until) {
+		rateLimitBackoffTotal.Inc()
+		return ErrRateLimited{Until: until}
+	}
+
+	zap.S().Infof("GitHub API rate limited. Waiting %v", d)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rl *rateLimiter) blockUntil(t time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if t.After(rl.blockedUntil) {
+		rl.blockedUntil = t
+	}
+}
+
+// observe records the rate limit headers of resp, and returns true if resp
+// is a rejection due to the (primary or secondary) rate limit.
+// https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api
+func (rl *rateLimiter) observe(hostName string, resp *http.Response) bool {
+	h := resp.Header
+	now := NowImpl()
+
+	var remaining int64 = -1
+	if v, err := strconv.ParseInt(h.Get("X-RateLimit-Limit"), 10, 64); err == nil {
+		rateLimitLimitGauge.WithLabelValues(hostName).Set(float64(v))
+	}
+	if v, err := strconv.ParseInt(h.Get("X-RateLimit-Remaining"), 10, 64); err == nil {
+		remaining = v
+		rateLimitRemainingGauge.WithLabelValues(hostName).Set(float64(v))
+	}
+	var reset time.Time
+	if v, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(v, 0)
+		rateLimitResetGauge.WithLabelValues(hostName).Set(float64(v))
+	}
+
+	if remaining == 0 && !reset.IsZero() {
+		rl.blockUntil(reset)
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	if v, err := strconv.ParseInt(h.Get("Retry-After"), 10, 64); err == nil {
+		rl.blockUntil(now.Add(time.Duration(v) * time.Second))
+		return true
+	}
+	if remaining == 0 {
+		return true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// Secondary rate limit without Retry-After. GitHub asks to wait at
+		// least a minute.
+		rl.blockUntil(now.Add(time.Minute))
+		return true
+	}
+	return false
+}
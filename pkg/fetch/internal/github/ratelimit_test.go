@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBackoff(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			t.Errorf("Unexpected Authorization header: %q", req.Header.Get("Authorization"))
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	host := NewHost("test.example", srv.URL, TokenAuth("t0ken"))
+
+	for i := 0; i < 3; i++ {
+		_, _, err := githubHttpGet(context.Background(), host, srv.URL)
+
+		var rlErr ErrRateLimited
+		if !errors.As(err, &rlErr) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if rlErr.Until.Unix() != reset {
+			t.Errorf("Expected backoff until %d, got %v", reset, rlErr.Until)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Expected the API to be hit only once, got %d", hits)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		if hits == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	host := NewHost("test.example", srv.URL, nil)
+
+	bs, _, err := githubHttpGet(context.Background(), host, srv.URL)
+	if err != nil {
+		t.Fatalf("Expected success after Retry-After, got %v", err)
+	}
+	if string(bs) != "[]" || hits != 2 {
+		t.Errorf("Unexpected result %q after %d hits", bs, hits)
+	}
+}
@@ -4,12 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"regexp"
-	"sort"
 	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
@@ -28,6 +26,7 @@ var secondsHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
 	Help: "Seconds took to fetch golang releases json.",
 })
 
+const HandlerName = "goruntime"
 const endpoint = "https://golang.org/dl/?mode=json&include=all"
 
 func getJson(ctx context.Context) ([]byte, error) {
@@ -36,33 +35,26 @@ func getJson(ctx context.Context) ([]byte, error) {
 		secondsHistogram.Observe(time.Since(start).Seconds())
 	}()
 
-	hc := httpcli.HttpClient
-
-	req, err := http.NewRequestWithContext((ctx), "GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to construct http.Request: %w", err)
-	}
-
-	resp, err := hc.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to issue request to %s: %w", endpoint, err)
-	}
-	defer resp.Body.Close()
-
-	bs, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to read body of %s: %w", endpoint, err)
-	}
-
-	return bs, nil
+	return httpcli.Get(ctx, endpoint)
 }
 
-var reGoSoftwareId = regexp.MustCompile(`^[Gg]o(lang)?$`)
+// "go:" is the explicit prefix, which may be followed by the usual name.
+var reGoSoftwareId = regexp.MustCompile(`^(go:|(go:)?[Gg]o(lang)?)$`)
 
 func Match(softwareId string) bool {
 	return reGoSoftwareId.MatchString(softwareId)
 }
 
+// changelogURLOf returns the release history entry of ver. The point
+// releases of a major version are listed under one "minor" anchor.
+func changelogURLOf(ver semver.Version) string {
+	anchor := fmt.Sprintf("go%d.%d", ver.Major, ver.Minor)
+	if ver.Patch != 0 {
+		anchor += ".minor"
+	}
+	return "https://go.dev/doc/devel/release#" + anchor
+}
+
 func Parse(jsonbs []byte) (releases.Releases, error) {
 	l := zap.S()
 
@@ -72,7 +64,7 @@ func Parse(jsonbs []byte) (releases.Releases, error) {
 		Arch     string `json:"arch"`
 		Version  string `json:"version"`
 		Sha256   string `json:"sha256"`
-		Size     int    `json:"size"`
+		Size     int64  `json:"size"`
 		Kind     string `json:"kind"`
 	}
 	type RawRelease struct {
@@ -94,20 +86,34 @@ func Parse(jsonbs []byte) (releases.Releases, error) {
 
 		r := releases.Release{
 			Prerelease: !rawr.Stable,
-			AssetURLs:  make([]string, 0, len(rawr.Files)),
+			Assets:     make([]releases.Asset, 0, len(rawr.Files)),
 		}
 
 		if ver, err := parser.ParseVersion(rawr.Version); err == nil {
 			r.OriginalName = rawr.Version
 			r.Version = ver
+			r.Components = parser.ParseComponents(rawr.Version)
+			if !r.Prerelease {
+				r.ChangelogURL = changelogURLOf(ver)
+			}
 		} else {
 			l.Warnf("Failed to parse Go version %q", rawr.Version)
 			continue
 		}
 
-		for _, a := range rawr.Files {
-			url := fmt.Sprintf("https://dl.google.com/go/%s", a.Filename)
-			r.AssetURLs = append(r.AssetURLs, url)
+		for _, f := range rawr.Files {
+			a := releases.Asset{
+				Name: f.Filename,
+				URL:  fmt.Sprintf("https://dl.google.com/go/%s", f.Filename),
+				Size: f.Size,
+				OS:   f.Os,
+				Arch: f.Arch,
+				Kind: f.Kind,
+			}
+			if f.Sha256 != "" {
+				a.Digests = map[string]string{"sha256": f.Sha256}
+			}
+			r.Assets = append(r.Assets, a)
 		}
 
 		rs = append(rs, r)
@@ -119,7 +125,7 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 	if !Match(softwareId) {
 		return nil, ferrors.ErrSoftwareIdParseFailed{
 			Input:       softwareId,
-			HandlerName: "goruntime",
+			HandlerName: HandlerName,
 			Err:         nil,
 		}
 	}
@@ -134,9 +140,7 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 		return nil, err
 	}
 
-	sort.SliceStable(rs, func(i, j int) bool {
-		return rs[i].Version.GT(rs[j].Version)
-	})
+	rs.Sort()
 
 	return rs, nil
 }
@@ -28,6 +28,9 @@ func TestMatch(t *testing.T) {
 		{"Go", true},
 		{"golang", true},
 		{"Golang", true},
+		{"go:", true},
+		{"go:golang", true},
+		{"go:rust", false},
 		{"github.com/google/ko", false},
 		{"Scala", false},
 	}
@@ -80,11 +83,39 @@ func TestParse(t *testing.T) {
 				]
 			 }]`,
 			releases.Releases{
-				{"go1.15.6", semver.MustParse("1.15.6"), false, []string{
-					"https://dl.google.com/go/go1.15.6.src.tar.gz",
-					"https://dl.google.com/go/go1.15.6.darwin-amd64.tar.gz",
-					"https://dl.google.com/go/go1.15.6.windows-amd64.msi",
-				}},
+				{
+					OriginalName: "go1.15.6",
+					ChangelogURL: "https://go.dev/doc/devel/release#go1.15.minor",
+					Version:      semver.MustParse("1.15.6"),
+					Prerelease:   false,
+					Assets: []releases.Asset{
+						{
+							Name:    "go1.15.6.src.tar.gz",
+							URL:     "https://dl.google.com/go/go1.15.6.src.tar.gz",
+							Size:    23019337,
+							Digests: map[string]string{"sha256": "890bba73c5e2b19ffb1180e385ea225059eb008eb91b694875dd86ea48675817"},
+							Kind:    "source",
+						},
+						{
+							Name:    "go1.15.6.darwin-amd64.tar.gz",
+							URL:     "https://dl.google.com/go/go1.15.6.darwin-amd64.tar.gz",
+							Size:    122234016,
+							Digests: map[string]string{"sha256": "940a73b45993a3bae5792cf324140dded34af97c548af4864d22fd6d49f3bd9f"},
+							OS:      "darwin",
+							Arch:    "amd64",
+							Kind:    "archive",
+						},
+						{
+							Name:    "go1.15.6.windows-amd64.msi",
+							URL:     "https://dl.google.com/go/go1.15.6.windows-amd64.msi",
+							Size:    120832000,
+							Digests: map[string]string{"sha256": "bedc8243116297d14a8ba15fcb280e7419dcf344a957263e2c815d74d463397e"},
+							OS:      "windows",
+							Arch:    "amd64",
+							Kind:    "installer",
+						},
+					},
+				},
 			},
 		},
 		{
@@ -103,9 +134,21 @@ func TestParse(t *testing.T) {
 				 }]
 			 }]`,
 			releases.Releases{
-				{"go1.15", semver.MustParse("1.15.0"), false, []string{
-					"https://dl.google.com/go/go1.15.src.tar.gz",
-				}},
+				{
+					OriginalName: "go1.15",
+					ChangelogURL: "https://go.dev/doc/devel/release#go1.15",
+					Version:      semver.MustParse("1.15.0"),
+					Prerelease:   false,
+					Assets: []releases.Asset{
+						{
+							Name:    "go1.15.src.tar.gz",
+							URL:     "https://dl.google.com/go/go1.15.src.tar.gz",
+							Size:    23002901,
+							Digests: map[string]string{"sha256": "69438f7ed4f532154ffaf878f3dfd83747e7a00b70b3556eddabf7aaee28ac3a"},
+							Kind:    "source",
+						},
+					},
+				},
 			},
 		},
 	}
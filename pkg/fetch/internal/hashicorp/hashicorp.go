@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"sort"
 
 	"go.uber.org/zap"
 
@@ -26,6 +25,12 @@ var Products = map[string]struct{}{
 	"consul": {},
 }
 
+// ChangelogRepos maps the products to their GitHub repositories known to
+// keep a CHANGELOG.md at the release tags.
+var ChangelogRepos = map[string]string{
+	"consul": "hashicorp/consul",
+}
+
 func Match(softwareId string) bool {
 	_, ok := Products[softwareId]
 	return ok
@@ -65,7 +70,6 @@ func Parse(softwareId string, htmlbs []byte) (releases.Releases, error) {
 		ver, err := parser.ParseVersion(versionStr)
 		if err != nil {
 			l.Debugf("Failed to parse version: %s", versionStr)
-			continue
 		}
 
 		assetURLs := make([]string, 0, len(assetSuffixes))
@@ -77,7 +81,12 @@ func Parse(softwareId string, htmlbs []byte) (releases.Releases, error) {
 		r := releases.Release{
 			OriginalName: versionStr,
 			Version:      ver,
-			AssetURLs:    assetURLs,
+			Components:   parser.ParseComponents(versionStr),
+			Unparsed:     err != nil,
+			Assets:       releases.NewAssets(assetURLs),
+		}
+		if repo, ok := ChangelogRepos[softwareId]; ok {
+			r.ChangelogURL = fmt.Sprintf("https://github.com/%s/blob/v%s/CHANGELOG.md", repo, versionStr)
 		}
 		rs = append(rs, r)
 	}
@@ -104,9 +113,7 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 		return nil, err
 	}
 
-	sort.SliceStable(rs, func(i, j int) bool {
-		return rs[i].Version.GT(rs[j].Version)
-	})
+	rs.Sort()
 
 	return rs, nil
 }
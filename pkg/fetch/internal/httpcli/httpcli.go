@@ -5,11 +5,31 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"time"
 )
 
+// HttpClient is shared by all providers. Its requests are retried, time out
+// and are revalidated as configured in the package variables.
 var HttpClient = &http.Client{
-	Timeout: time.Second * 10,
+	Transport: &Transport{ApplyTimeouts: true, Revalidate: true},
+}
+
+// DownloadClient is HttpClient without the timeouts and revalidation, for
+// downloading assets which may be large. Requests are bounded by their
+// context instead.
+var DownloadClient = &http.Client{
+	Transport: &Transport{},
+}
+
+// StatusError is returned by Get when the server responds with a non-2xx
+// status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %s", e.URL, e.Status)
 }
 
 func Get(ctx context.Context, url string) ([]byte, error) {
@@ -24,6 +44,10 @@ func Get(ctx context.Context, url string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
 	bs, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to read body of %s: %w", url, err)
@@ -0,0 +1,164 @@
+package httpcli
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var revalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "latest",
+	Subsystem: "http",
+	Name:      "revalidations_total",
+
+	Help: "Number of conditional requests, by host and whether the cached body was reused.",
+}, []string{"host", "result"})
+
+// MaxValidatedBytes bounds the total size of the response bodies kept for
+// revalidation. The least recently used ones are evicted first.
+var MaxValidatedBytes int64 = 64 << 20
+
+type validated struct {
+	key          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+type validatedCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int64
+}
+
+var validatedResponses = &validatedCache{
+	entries: make(map[string]*list.Element),
+	lru:     list.New(),
+}
+
+func (c *validatedCache) get(key string) (*validated, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*validated), true
+}
+
+func (c *validatedCache) put(v *validated) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[v.key]; ok {
+		c.size -= int64(len(el.Value.(*validated).body))
+		c.lru.Remove(el)
+		delete(c.entries, v.key)
+	}
+	if int64(len(v.body)) > MaxValidatedBytes {
+		return
+	}
+
+	c.entries[v.key] = c.lru.PushFront(v)
+	c.size += int64(len(v.body))
+	for c.size > MaxValidatedBytes {
+		el := c.lru.Back()
+		old := el.Value.(*validated)
+		c.size -= int64(len(old.body))
+		c.lru.Remove(el)
+		delete(c.entries, old.key)
+	}
+}
+
+// keyOf identifies the representation of the resource, which may differ by
+// the Accept and Authorization headers, e.g. private GitHub repositories.
+func keyOf(req *http.Request) string {
+	authz := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + "\x00" + req.Header.Get("Accept") + "\x00" + hex.EncodeToString(authz[:])
+}
+
+func isRevalidatable(req *http.Request) bool {
+	return req.Method == "GET" &&
+		req.Header.Get("If-None-Match") == "" &&
+		req.Header.Get("If-Modified-Since") == "" &&
+		req.Header.Get("Range") == ""
+}
+
+// revalidate sends req with the validators of the previous response, and
+// reuses its body if the server responds 304 Not Modified.
+func (t *Transport) revalidate(req *http.Request) (*http.Response, error) {
+	key := keyOf(req)
+	prev, ok := validatedResponses.get(key)
+	if ok {
+		req = req.Clone(req.Context())
+		if prev.etag != "" {
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		if prev.lastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.lastModified)
+		}
+	}
+
+	resp, err := t.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		revalidationsTotal.WithLabelValues(req.URL.Host, "not_modified").Inc()
+
+		// The headers of the 304 are fresher, e.g. the rate limit headers.
+		header := prev.header.Clone()
+		for k, vs := range resp.Header {
+			header[k] = vs
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(prev.body)),
+			ContentLength: int64(len(prev.body)),
+			Request:       req,
+		}, nil
+	}
+	if ok {
+		revalidationsTotal.WithLabelValues(req.URL.Host, "modified").Inc()
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	validatedResponses.put(&validated{
+		key:          key,
+		etag:         etag,
+		lastModified: lastModified,
+		header:       resp.Header.Clone(),
+		body:         bs,
+	})
+	resp.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	return resp, nil
+}
@@ -0,0 +1,104 @@
+package httpcli
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRevalidate(t *testing.T) {
+	body := "v1"
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		etag := `"` + body + `"`
+		w.Header().Set("X-Request-Count", "fresh")
+		switch req.URL.Path {
+		case "/etag":
+			if req.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		case "/last-modified":
+			lastModified := "Mon, 01 Jan 2024 00:00:00 GMT"
+			if req.Header.Get("If-Modified-Since") == lastModified {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		full++
+		w.Header().Set("Link", `<next>; rel="next"`)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	get := func(path string) string {
+		bs, err := Get(ctx, srv.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bs)
+	}
+
+	for _, path := range []string{"/etag", "/last-modified"} {
+		full, notModified = 0, 0
+		if s1, s2 := get(path), get(path); s1 != "v1" || s2 != "v1" || full != 1 || notModified != 1 {
+			t.Errorf("%s: got %q, %q with %d full and %d not modified responses", path, s1, s2, full, notModified)
+		}
+	}
+
+	// The headers of the cached response are kept.
+	req, err := http.NewRequest("GET", srv.URL+"/etag", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := HttpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Link") == "" {
+		t.Errorf("Unexpected response to revalidation: %s %v", resp.Status, resp.Header)
+	}
+
+	full, notModified = 0, 0
+	body = "v2"
+	if s := get("/etag"); s != "v2" || full != 1 || notModified != 0 {
+		t.Errorf("Expected the modified body, got %q", s)
+	}
+
+	// Responses without validators are not kept.
+	full = 0
+	get("/none")
+	get("/none")
+	if full != 2 {
+		t.Errorf("Expected 2 full responses, got %d", full)
+	}
+}
+
+func TestValidatedCacheEviction(t *testing.T) {
+	defer func(n int64) { MaxValidatedBytes = n }(MaxValidatedBytes)
+	MaxValidatedBytes = 10
+
+	c := &validatedCache{entries: make(map[string]*list.Element), lru: list.New()}
+	c.put(&validated{key: "a", body: []byte("aaaa")})
+	c.put(&validated{key: "b", body: []byte("bbbb")})
+	c.get("a")
+	c.put(&validated{key: "c", body: []byte("cccc")})
+	c.put(&validated{key: "huge", body: []byte("hhhhhhhhhhh")})
+
+	for key, expected := range map[string]bool{"a": true, "b": false, "c": true, "huge": false} {
+		if _, ok := c.get(key); ok != expected {
+			t.Errorf("Expected %q cached %t", key, expected)
+		}
+	}
+	if c.size != 8 {
+		t.Errorf("Unexpected size %d", c.size)
+	}
+}
@@ -0,0 +1,263 @@
+package httpcli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/version"
+)
+
+var retriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "latest",
+	Subsystem: "http",
+	Name:      "retries_total",
+
+	Help: "Number of HTTP requests retried, by host.",
+}, []string{"host"})
+
+var (
+	UserAgent = fmt.Sprintf("latest/%s (+https://github.com/IPA-CyberLab/latest)", version.Version)
+
+	// Timeout bounds each attempt of a request, including reading the
+	// response body. HostTimeouts override it per host, as in URL.Host.
+	Timeout      = 10 * time.Second
+	HostTimeouts = map[string]time.Duration{}
+
+	// MaxRetries is the number of times a request is retried on network
+	// errors, 5xx and 429 responses. The delay doubles from RetryBaseDelay
+	// up to RetryMaxDelay, with jitter. Retry-After is respected unless it
+	// exceeds RetryMaxDelay, in which case the response is returned as is.
+	MaxRetries     = 3
+	RetryBaseDelay = 500 * time.Millisecond
+	RetryMaxDelay  = 30 * time.Second
+
+	// Offline refuses all requests with ErrOffline.
+	Offline = false
+)
+
+var ErrOffline = errors.New("Network access is disabled in offline mode")
+
+// baseTransport proxies requests as specified by $HTTP_PROXY, $HTTPS_PROXY
+// and $NO_PROXY.
+var baseTransport = newBaseTransport(nil)
+
+func newBaseTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	t.TLSClientConfig = tlsConfig
+	return t
+}
+
+// ConfigureTLS trusts the CA certificates in caFile in addition to the
+// system ones, and presents the client certificate in certFile and keyFile
+// if specified.
+func ConfigureTLS(caFile, certFile, keyFile string) error {
+	if caFile == "" && certFile == "" {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("Failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("No certificate found in CA bundle %q", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" {
+		if keyFile == "" {
+			keyFile = certFile
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("Failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	baseTransport = newBaseTransport(tlsConfig)
+	return nil
+}
+
+type ctxKey int
+
+const noRateLimitRetryKey ctxKey = iota
+
+// WithoutRateLimitRetry returns a context whose requests are not retried on
+// 429 responses, for callers handling the rate limit by themselves.
+func WithoutRateLimitRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRateLimitRetryKey, true)
+}
+
+// Transport sets the User-Agent, retries and, if ApplyTimeouts, times out
+// requests. Requests with bodies are retried only if replayable, i.e. with
+// GetBody set. If Revalidate, GET requests are made conditional on the
+// validators of the previous response to the same URL.
+type Transport struct {
+	// Base defaults to a transport configured by ConfigureTLS.
+	Base          http.RoundTripper
+	ApplyTimeouts bool
+	Revalidate    bool
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return baseTransport
+}
+
+func timeoutOf(host string) time.Duration {
+	if d, ok := HostTimeouts[host]; ok {
+		return d
+	}
+	return Timeout
+}
+
+// cancelOnClose releases the context of the attempt once the body is read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func isRetriableStatus(req *http.Request, code int) bool {
+	if code == http.StatusTooManyRequests {
+		return req.Context().Value(noRateLimitRetryKey) == nil
+	}
+	return code >= 500 && code != http.StatusNotImplemented
+}
+
+// retryDelay returns the delay before the retry following attempt, and false
+// if the server asks to wait longer than RetryMaxDelay.
+func retryDelay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			var d time.Duration
+			if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+				d = time.Duration(secs) * time.Second
+			} else if t, err := http.ParseTime(v); err == nil {
+				d = time.Until(t)
+			}
+			if d > RetryMaxDelay {
+				return 0, false
+			}
+			if d > 0 {
+				return d, true
+			}
+		}
+	}
+
+	d := RetryBaseDelay << uint(attempt)
+	if d > RetryMaxDelay || d <= 0 {
+		d = RetryMaxDelay
+	}
+	// Full jitter over the upper half, so that concurrent clients spread.
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	return d, true
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Offline {
+		return nil, ErrOffline
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	if t.Revalidate && isRevalidatable(req) {
+		return t.revalidate(req)
+	}
+	return t.roundTrip(req)
+}
+
+// roundTrip issues req, retrying as configured.
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	l := zap.S()
+
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		areq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			areq = req.Clone(req.Context())
+			areq.Body = body
+		}
+
+		cancel := context.CancelFunc(func() {})
+		if t.ApplyTimeouts {
+			if d := timeoutOf(req.URL.Host); d > 0 {
+				var ctx context.Context
+				ctx, cancel = context.WithTimeout(req.Context(), d)
+				areq = areq.WithContext(ctx)
+			}
+		}
+
+		resp, err := t.base().RoundTrip(areq)
+
+		retriable := attempt < MaxRetries && replayable && req.Context().Err() == nil
+		if err == nil {
+			retriable = retriable && isRetriableStatus(req, resp.StatusCode)
+		}
+		var delay time.Duration
+		if retriable {
+			delay, retriable = retryDelay(attempt, resp)
+		}
+		if !retriable {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = cancelOnClose{resp.Body, cancel}
+			return resp, nil
+		}
+
+		if err != nil {
+			l.Debugf("Retrying %s %s in %v: %v", req.Method, req.URL, delay, err)
+		} else {
+			l.Debugf("Retrying %s %s in %v: status %s", req.Method, req.URL, delay, resp.Status)
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		cancel()
+		retriesTotal.WithLabelValues(req.URL.Host).Inc()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package httpcli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	RetryBaseDelay = time.Millisecond
+	defer func() { RetryBaseDelay = 500 * time.Millisecond }()
+
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits[req.URL.Path]++
+		if !strings.HasPrefix(req.Header.Get("User-Agent"), "latest/") {
+			t.Errorf("Unexpected User-Agent %q", req.Header.Get("User-Agent"))
+		}
+
+		switch req.URL.Path {
+		case "/flaky":
+			if hits[req.URL.Path] < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		case "/notfound":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/ratelimited":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	bs, err := Get(ctx, srv.URL+"/flaky")
+	if err != nil || string(bs) != "ok" || hits["/flaky"] != 3 {
+		t.Errorf("Expected success after 2 retries, got %q, %v after %d hits", bs, err, hits["/flaky"])
+	}
+
+	var se StatusError
+	if _, err := Get(ctx, srv.URL+"/down"); !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected StatusError, got %v", err)
+	}
+	if hits["/down"] != MaxRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", MaxRetries+1, hits["/down"])
+	}
+
+	if _, err := Get(ctx, srv.URL+"/notfound"); !errors.As(err, &se) || hits["/notfound"] != 1 {
+		t.Errorf("Expected 404 to fail without retries, got %v after %d hits", err, hits["/notfound"])
+	}
+
+	// Retry-After beyond RetryMaxDelay is left to the caller.
+	if _, err := Get(ctx, srv.URL+"/ratelimited"); err == nil || hits["/ratelimited"] != 1 {
+		t.Errorf("Expected 429 to fail without retries, got %v after %d hits", err, hits["/ratelimited"])
+	}
+}
+
+func TestWithoutRateLimitRetry(t *testing.T) {
+	RetryBaseDelay = time.Millisecond
+	defer func() { RetryBaseDelay = 500 * time.Millisecond }()
+
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	if _, err := Get(WithoutRateLimitRetry(context.Background()), srv.URL); err == nil || hits != 1 {
+		t.Errorf("Expected 429 to fail without retries, got %v after %d hits", err, hits)
+	}
+}
+
+func TestHostTimeouts(t *testing.T) {
+	RetryBaseDelay = time.Millisecond
+	defer func() { RetryBaseDelay = 500 * time.Millisecond }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	HostTimeouts[u.Host] = 20 * time.Millisecond
+	defer delete(HostTimeouts, u.Host)
+
+	if _, err := Get(context.Background(), srv.URL); err == nil {
+		t.Errorf("Expected the per-host timeout to expire")
+	}
+
+	HostTimeouts[u.Host] = time.Second
+	if bs, err := Get(context.Background(), srv.URL); err != nil || string(bs) != "slow" {
+		t.Errorf("Expected success, got %q, %v", bs, err)
+	}
+}
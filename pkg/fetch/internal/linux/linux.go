@@ -0,0 +1,163 @@
+package linux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const HandlerName = "linux"
+const endpoint = "https://www.kernel.org/releases.json"
+
+// Monikers maps the flag specified in softwareId (e.g. "linux:longterm") to
+// the monikers used in releases.json.
+var Monikers = map[string][]string{
+	"":         {"mainline", "stable", "longterm"},
+	"mainline": {"mainline"},
+	"stable":   {"stable"},
+	"longterm": {"longterm"},
+	"next":     {"linux-next"},
+}
+
+var reLinux = regexp.MustCompile(`^[Ll]inux(:([\w\-]+))?$`)
+
+func parseMonikers(softwareId string) ([]string, bool) {
+	ms := reLinux.FindStringSubmatch(softwareId)
+	if len(ms) == 0 {
+		return nil, false
+	}
+
+	flag := ms[2]
+	if flag == "linux-next" {
+		flag = "next"
+	}
+	monikers, ok := Monikers[flag]
+	return monikers, ok
+}
+
+func Match(softwareId string) bool {
+	_, ok := parseMonikers(softwareId)
+	return ok
+}
+
+var reRc = regexp.MustCompile(`^(\d+)\.(\d+)-rc(\d+)$`)
+
+// ParseVersion parses kernel version strings. Mainline release candidates
+// such as "6.8-rc1" are mapped to "6.8.0-rc.1" so that they are ordered
+// numerically.
+func ParseVersion(s string) (semver.Version, error) {
+	if ms := reRc.FindStringSubmatch(s); len(ms) != 0 {
+		return semver.Parse(fmt.Sprintf("%s.%s.0-rc.%s", ms[1], ms[2], ms[3]))
+	}
+
+	return parser.ParseVersion(s)
+}
+
+func Parse(jsonbs []byte, monikers []string) (releases.Releases, error) {
+	l := zap.S()
+
+	type Released struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	type Patch struct {
+		Full        string `json:"full"`
+		Incremental string `json:"incremental"`
+	}
+	type RawRelease struct {
+		Version   string   `json:"version"`
+		Moniker   string   `json:"moniker"`
+		Source    string   `json:"source"`
+		PGP       string   `json:"pgp"`
+		Released  Released `json:"released"`
+		Patch     Patch    `json:"patch"`
+		Changelog string   `json:"changelog"`
+	}
+	type Response struct {
+		Releases []RawRelease `json:"releases"`
+	}
+
+	var resp Response
+	if err := json.Unmarshal(jsonbs, &resp); err != nil {
+		return nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	wanted := make(map[string]struct{})
+	for _, m := range monikers {
+		wanted[m] = struct{}{}
+	}
+
+	rs := make(releases.Releases, 0, len(resp.Releases))
+	for _, rawr := range resp.Releases {
+		if _, ok := wanted[rawr.Moniker]; !ok {
+			continue
+		}
+
+		ver, err := ParseVersion(rawr.Version)
+		if err != nil {
+			l.Warnf("Failed to parse kernel version %q: %v", rawr.Version, err)
+			continue
+		}
+
+		assets := make([]releases.Asset, 0, 4)
+		for _, u := range []string{rawr.Source, rawr.PGP, rawr.Patch.Full, rawr.Patch.Incremental} {
+			if u == "" {
+				continue
+			}
+			a := releases.NewAsset(u)
+			if u == rawr.Source {
+				a.Kind = "source"
+				// The signature is of the uncompressed tarball.
+				a.SignatureURL = rawr.PGP
+			}
+			assets = append(assets, a)
+		}
+
+		r := releases.Release{
+			OriginalName: rawr.Version,
+			Version:      ver,
+			Prerelease:   rawr.Moniker == "linux-next" || len(ver.Pre) > 0,
+			Assets:       assets,
+			ChangelogURL: rawr.Changelog,
+		}
+		if rawr.Released.Timestamp != 0 {
+			r.PublishedAt = time.Unix(rawr.Released.Timestamp, 0).UTC()
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	monikers, ok := parseMonikers(softwareId)
+	if !ok {
+		return nil, ferrors.ErrSoftwareIdParseFailed{
+			Input:       softwareId,
+			HandlerName: HandlerName,
+			Err:         nil,
+		}
+	}
+
+	bs, err := httpcli.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := Parse(bs, monikers)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.Sort()
+
+	return rs, nil
+}
@@ -0,0 +1,117 @@
+package linux_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/linux"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const releasesJson = `{
+  "latest_stable": {"version": "6.7.1"},
+  "releases": [
+    {
+      "iseol": false,
+      "version": "6.8-rc1",
+      "moniker": "mainline",
+      "source": "https://git.kernel.org/torvalds/t/linux-6.8-rc1.tar.gz",
+      "pgp": null,
+      "released": {"timestamp": 1705877437, "isodate": "2024-01-21"},
+      "patch": {"full": "https://git.kernel.org/torvalds/p/v6.8-rc1/v6.7", "incremental": null}
+    },
+    {
+      "iseol": false,
+      "version": "6.7.1",
+      "moniker": "stable",
+      "source": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.7.1.tar.xz",
+      "pgp": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.7.1.tar.sign",
+      "released": {"timestamp": 1705681466, "isodate": "2024-01-19"},
+      "patch": {
+        "full": "https://cdn.kernel.org/pub/linux/kernel/v6.x/patch-6.7.1.xz",
+        "incremental": "https://cdn.kernel.org/pub/linux/kernel/v6.x/incr/patch-6.7-1.xz"
+      }
+    },
+    {
+      "iseol": false,
+      "version": "6.6.13",
+      "moniker": "longterm",
+      "source": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.6.13.tar.xz",
+      "pgp": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.6.13.tar.sign",
+      "released": {"timestamp": 1705681210, "isodate": "2024-01-19"},
+      "patch": {"full": "https://cdn.kernel.org/pub/linux/kernel/v6.x/patch-6.6.13.xz", "incremental": null}
+    },
+    {
+      "iseol": false,
+      "version": "next-20240125",
+      "moniker": "linux-next",
+      "source": null,
+      "pgp": null,
+      "released": {"timestamp": 1706170330, "isodate": "2024-01-25"},
+      "patch": {"full": null, "incremental": null}
+    }
+  ]
+}`
+
+func TestMatch(t *testing.T) {
+	testcases := []struct {
+		input       string
+		expectMatch bool
+	}{
+		{"linux", true},
+		{"linux:longterm", true},
+		{"linux:linux-next", true},
+		{"linux:nosuchmoniker", false},
+		{"linuxkit", false},
+	}
+
+	for _, tc := range testcases {
+		actual := linux.Match(tc.input)
+		if actual != tc.expectMatch {
+			t.Errorf("Match(%q) expected %t actual %t", tc.input, tc.expectMatch, actual)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	rs, err := linux.Parse([]byte(releasesJson), linux.Monikers["longterm"])
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	expected := releases.Releases{
+		{
+			OriginalName: "6.6.13",
+			Version:      semver.MustParse("6.6.13"),
+			Prerelease:   false,
+			Assets: []releases.Asset{
+				{
+					Name:         "linux-6.6.13.tar.xz",
+					URL:          "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.6.13.tar.xz",
+					SignatureURL: "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.6.13.tar.sign",
+					Kind:         "source",
+				},
+				releases.NewAsset("https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.6.13.tar.sign"),
+				releases.NewAsset("https://cdn.kernel.org/pub/linux/kernel/v6.x/patch-6.6.13.xz"),
+			},
+			PublishedAt: time.Unix(1705681210, 0).UTC(),
+		},
+	}
+	if diffstr := cmp.Diff(rs, expected); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+
+	rs, err = linux.Parse([]byte(releasesJson), linux.Monikers[""])
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if len(rs) != 3 {
+		t.Fatalf("Expected 3 releases, got %d", len(rs))
+	}
+	if rs[0].Version.String() != "6.8.0-rc.1" || !rs[0].Prerelease {
+		t.Errorf("Unexpected mainline release: %+v", rs[0])
+	}
+}
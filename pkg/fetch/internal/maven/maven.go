@@ -5,19 +5,142 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
 	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
-	"github.com/IPA-CyberLab/latest/pkg/parser"
 	"github.com/IPA-CyberLab/latest/pkg/releases"
+	"github.com/IPA-CyberLab/latest/pkg/scheme"
 )
 
-// FIXME: make configurable
-const MavenRoot = "https://repo1.maven.org/maven2"
+const HandlerName = "maven"
+
+var errNotFound = errors.New("not found")
+
+func mavenHttpGet(ctx context.Context, repo Repository, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to construct http.Request: %w", err)
+	}
+
+	repo.authorize(req)
+
+	resp, err := httpcli.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to issue request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Maven repository %q returned status %s", repo.Id, resp.Status)
+	}
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read body of %s: %w", url, err)
+	}
+
+	return bs, nil
+}
+
+// lastUpdatedLayout is the layout of lastUpdated in maven-metadata.xml, in UTC.
+const lastUpdatedLayout = "20060102150405"
+
+type metadata struct {
+	Latest      string   `xml:"versioning>latest"`
+	Release     string   `xml:"versioning>release"`
+	Versions    []string `xml:"versioning>versions>version"`
+	LastUpdated string   `xml:"versioning>lastUpdated"`
+}
+
+func projectRootOf(repo Repository, groupId, artifactId string) string {
+	return fmt.Sprintf("%s/%s/%s", repo.URL, strings.ReplaceAll(groupId, ".", "/"), artifactId)
+}
+
+func fetchMetadata(ctx context.Context, repo Repository, groupId, artifactId string) (*metadata, error) {
+	metadataUrl := fmt.Sprintf("%s/maven-metadata.xml", projectRootOf(repo, groupId, artifactId))
+	zap.S().Debugf("url: %s", metadataUrl)
+
+	metadataXml, err := mavenHttpGet(ctx, repo, metadataUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	// http://maven.apache.org/ref/3.3.9/maven-repository-metadata/repository-metadata.html
+
+	var md metadata
+	if err := xml.Unmarshal(metadataXml, &md); err != nil {
+		return nil, fmt.Errorf("Failed to parse maven-metadata.xml from %q: %w", repo.Id, err)
+	}
+	return &md, nil
+}
+
+// Maven repositories serve checksums and signatures next to each artifact.
+// https://maven.apache.org/resolver/about-checksums.html
+var checksumAlgorithms = []string{"sha1", "sha256", "sha512"}
+
+func assetOf(assetURL string) releases.Asset {
+	a := releases.NewAsset(assetURL)
+	a.ChecksumURLs = make(map[string]string, len(checksumAlgorithms))
+	a.SignatureURL = assetURL + ".asc"
+	for _, algo := range checksumAlgorithms {
+		a.ChecksumURLs[algo] = assetURL + "." + algo
+	}
+	return a
+}
+
+// ResolveSnapshots makes Fetch look up the per-version maven-metadata.xml of
+// "-SNAPSHOT" versions, so that their assets point to the latest timestamped
+// artifact instead of the non-timestamped one, which most remote repositories
+// do not serve.
+var ResolveSnapshots = false
+
+func resolveSnapshot(ctx context.Context, repo Repository, groupId, artifactId, versionStr, classifier, extension string) (string, error) {
+	metadataUrl := fmt.Sprintf("%s/%s/maven-metadata.xml", projectRootOf(repo, groupId, artifactId), versionStr)
+
+	metadataXml, err := mavenHttpGet(ctx, repo, metadataUrl)
+	if err != nil {
+		return "", err
+	}
+
+	type SnapshotMetadata struct {
+		Timestamp        string `xml:"versioning>snapshot>timestamp"`
+		BuildNumber      string `xml:"versioning>snapshot>buildNumber"`
+		SnapshotVersions []struct {
+			Classifier string `xml:"classifier"`
+			Extension  string `xml:"extension"`
+			Value      string `xml:"value"`
+		} `xml:"versioning>snapshotVersions>snapshotVersion"`
+	}
+
+	var md SnapshotMetadata
+	if err := xml.Unmarshal(metadataXml, &md); err != nil {
+		return "", fmt.Errorf("Failed to parse maven-metadata.xml of %s: %w", versionStr, err)
+	}
+
+	for _, sv := range md.SnapshotVersions {
+		if sv.Classifier == classifier && sv.Extension == extension && sv.Value != "" {
+			return sv.Value, nil
+		}
+	}
+	if md.Timestamp != "" && md.BuildNumber != "" {
+		return fmt.Sprintf("%s-%s-%s", strings.TrimSuffix(versionStr, "-SNAPSHOT"), md.Timestamp, md.BuildNumber), nil
+	}
+	return "", fmt.Errorf("No snapshot version found in maven-metadata.xml of %s", versionStr)
+}
+
+func Match(softwareId string) bool {
+	return strings.HasPrefix(softwareId, "m2:")
+}
 
 func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 	l := zap.S()
@@ -27,14 +150,14 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 	if ss[0] != "m2" {
 		return nil, ferrors.ErrSoftwareIdParseFailed{
 			Input:       softwareId,
-			HandlerName: "maven",
+			HandlerName: HandlerName,
 			Err:         errors.New("bad prefix"),
 		}
 	}
 	if len(ss) < 3 || len(ss) > 5 {
 		return nil, ferrors.ErrSoftwareIdParseFailed{
 			Input:       softwareId,
-			HandlerName: "maven",
+			HandlerName: HandlerName,
 			Err:         errors.New("unexpected number of :s"),
 		}
 	}
@@ -47,71 +170,98 @@ func Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
 		extension = ss[4]
 	}
 
-	projectRoot := fmt.Sprintf("%s/%s/%s", MavenRoot, strings.ReplaceAll(groupId, ".", "/"), artifactId)
-	metadataUrl := fmt.Sprintf("%s/maven-metadata.xml", projectRoot)
-	l.Debugf("url: %s", metadataUrl)
-
-	metadataXml, err := httpcli.Get(ctx, metadataUrl)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to get maven-metadata.xml: %w", err)
+	type found struct {
+		repo        Repository
+		comparable  scheme.ComparableVersion
+		publishedAt time.Time
 	}
+	versionsFound := make(map[string]found)
+	versionStrs := make([]string, 0)
+	var lastErr error
+	for _, repo := range Repositories {
+		md, err := fetchMetadata(ctx, repo, groupId, artifactId)
+		if err != nil {
+			if !errors.Is(err, errNotFound) {
+				l.Warnf("Failed to get maven-metadata.xml from %q: %v", repo.Id, err)
+				lastErr = err
+			}
+			continue
+		}
+		l.Debugf("metadata from %q: %+v", repo.Id, md)
 
-	// http://maven.apache.org/ref/3.3.9/maven-repository-metadata/repository-metadata.html
+		// The metadata records only when it was last updated, which is when
+		// its latest version was deployed.
+		lastUpdated, err := time.Parse(lastUpdatedLayout, md.LastUpdated)
+		if err != nil {
+			l.Debugf("Failed to parse lastUpdated %q from %q: %v", md.LastUpdated, repo.Id, err)
+		}
 
-	type Metadata struct {
-		Latest      string   `xml:"versioning>latest"`
-		Release     string   `xml:"versioning>release"`
-		Versions    []string `xml:"versioning>versions>version"`
-		LastUpdated string   `xml:"versioning>lastUpdated"`
+		for _, versionStr := range md.Versions {
+			if _, ok := versionsFound[versionStr]; ok {
+				continue
+			}
+			f := found{repo: repo, comparable: scheme.ParseComparableVersion(versionStr)}
+			if versionStr == md.Latest {
+				f.publishedAt = lastUpdated
+			}
+			versionsFound[versionStr] = f
+			versionStrs = append(versionStrs, versionStr)
+		}
 	}
-
-	var metadata Metadata
-	if err := xml.Unmarshal(metadataXml, &metadata); err != nil {
-		return nil, fmt.Errorf("Failed to parse maven-metadata.xml: %w", err)
+	if len(versionsFound) == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("Failed to get maven-metadata.xml: %w", lastErr)
+		}
+		return nil, fmt.Errorf("Failed to find %s:%s in any of the Maven repositories", groupId, artifactId)
 	}
 
-	rs := make(releases.Releases, 0, len(metadata.Versions))
-	l.Debugf("metadata: %+v", metadata)
+	// Order by Maven's own version ordering rather than semver, which
+	// mis-orders qualifiers such as "-RC1" and ".Final".
+	sort.SliceStable(versionStrs, func(i, j int) bool {
+		return versionsFound[versionStrs[i]].comparable.Compare(versionsFound[versionStrs[j]].comparable) > 0
+	})
+
+	rs := make(releases.Releases, 0, len(versionStrs))
 	l.Debugf("classifier %q extension %q", classifier, extension)
 
-	/*
-		verLatest, err := parser.ParseVersion(metadata.Latest)
-		if err != nil {
-			l.Warnf("Failed to parse latest version %q: %v", metadata.Latest, err)
-		}
-	*/
+	for _, versionStr := range versionStrs {
+		f := versionsFound[versionStr]
 
-	verRelease, err := parser.ParseVersion(metadata.Release)
-	if err != nil {
-		l.Warnf("Failed to parse release version %q: %v", metadata.Release, err)
-	}
+		prerelease := f.comparable.IsPrerelease()
+		version, ok := scheme.MavenToSemver(versionStr, prerelease)
+		if !ok {
+			l.Warnf("Failed to parse version %q", versionStr)
+			continue
+		}
 
-	for _, versionStr := range metadata.Versions {
-		version, err := parser.ParseVersion(versionStr)
-		if err != nil {
-			l.Warnf("Failed to parse version %q: %v", versionStr, err)
+		fileVersion := versionStr
+		if ResolveSnapshots && strings.HasSuffix(versionStr, "-SNAPSHOT") {
+			resolved, err := resolveSnapshot(ctx, f.repo, groupId, artifactId, versionStr, classifier, extension)
+			if err != nil {
+				l.Debugf("Failed to resolve snapshot %q, using the non-timestamped artifact: %v", versionStr, err)
+			} else {
+				fileVersion = resolved
+			}
 		}
 
 		var filename string
 		if classifier == "" {
-			filename = fmt.Sprintf("%s-%s.%s", artifactId, versionStr, extension)
+			filename = fmt.Sprintf("%s-%s.%s", artifactId, fileVersion, extension)
 		} else {
-			filename = fmt.Sprintf("%s-%s-%s.%s", artifactId, versionStr, classifier, extension)
+			filename = fmt.Sprintf("%s-%s-%s.%s", artifactId, fileVersion, classifier, extension)
 		}
-		assetURL := fmt.Sprintf("%s/%s/%s", projectRoot, versionStr, filename)
+		assetURL := fmt.Sprintf("%s/%s/%s", projectRootOf(f.repo, groupId, artifactId), versionStr, filename)
 
 		r := releases.Release{
 			OriginalName: versionStr,
 			Version:      version,
-			Prerelease:   version.GT(verRelease),
-			AssetURLs:    []string{assetURL},
+			Prerelease:   prerelease,
+			Assets:       []releases.Asset{assetOf(assetURL)},
+			PublishedAt:  f.publishedAt,
+			Source:       f.repo.Id,
 		}
 		rs = append(rs, r)
 	}
 
-	sort.SliceStable(rs, func(i, j int) bool {
-		return rs[i].Version.GT(rs[j].Version)
-	})
-
 	return rs, nil
 }
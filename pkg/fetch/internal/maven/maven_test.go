@@ -0,0 +1,79 @@
+package maven
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/central/com/example/lib/maven-metadata.xml", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`<metadata><versioning>
+			<release>1.1</release>
+			<versions><version>1.0</version><version>1.1-RC1</version><version>1.1</version></versions>
+		</versioning></metadata>`))
+	})
+	mux.HandleFunc("/internal/com/example/lib/maven-metadata.xml", func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "u" || pass != "p" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`<metadata><versioning>
+			<latest>1.2-SNAPSHOT</latest>
+			<versions><version>1.1</version><version>1.2-SNAPSHOT</version></versions>
+			<lastUpdated>20240101123456</lastUpdated>
+		</versioning></metadata>`))
+	})
+	mux.HandleFunc("/internal/com/example/lib/1.2-SNAPSHOT/maven-metadata.xml", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`<metadata><versioning>
+			<snapshot><timestamp>20240101.123456</timestamp><buildNumber>3</buildNumber></snapshot>
+		</versioning></metadata>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	origRepos, origResolve := Repositories, ResolveSnapshots
+	defer func() { Repositories, ResolveSnapshots = origRepos, origResolve }()
+	Repositories = []Repository{
+		{Id: "internal", URL: srv.URL + "/internal", Username: "u", Password: "p"},
+		{Id: "central", URL: srv.URL + "/central"},
+	}
+	ResolveSnapshots = true
+
+	rs, err := Fetch(context.Background(), "m2:com.example:lib")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	type summary struct {
+		Name       string
+		Prerelease bool
+		Source     string
+		AssetURLs  []string
+	}
+	actual := make([]summary, 0, len(rs))
+	for _, r := range rs {
+		actual = append(actual, summary{r.OriginalName, r.Prerelease, r.Source, r.AssetURLs()})
+	}
+	expected := []summary{
+		{"1.2-SNAPSHOT", true, "internal", []string{srv.URL + "/internal/com/example/lib/1.2-SNAPSHOT/lib-1.2-20240101.123456-3.jar"}},
+		{"1.1", false, "internal", []string{srv.URL + "/internal/com/example/lib/1.1/lib-1.1.jar"}},
+		{"1.1-RC1", true, "central", []string{srv.URL + "/central/com/example/lib/1.1-RC1/lib-1.1-RC1.jar"}},
+		{"1.0", false, "central", []string{srv.URL + "/central/com/example/lib/1.0/lib-1.0.jar"}},
+	}
+	if diffstr := cmp.Diff(actual, expected); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+
+	if expected := time.Date(2024, 1, 1, 12, 34, 56, 0, time.UTC); !rs[0].PublishedAt.Equal(expected) {
+		t.Errorf("Expected the latest version published at %v, got %v", expected, rs[0].PublishedAt)
+	}
+	if !rs[1].PublishedAt.IsZero() {
+		t.Errorf("Expected unknown publication time, got %v", rs[1].PublishedAt)
+	}
+}
@@ -0,0 +1,240 @@
+package maven
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const CentralURL = "https://repo1.maven.org/maven2"
+
+type Repository struct {
+	Id  string
+	URL string
+
+	// Username and Password are used for basic auth if Username is non-empty.
+	Username string
+	Password string
+	// Token is sent as a bearer token if non-empty.
+	Token string
+}
+
+func (repo Repository) authorize(req *http.Request) {
+	if repo.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+repo.Token)
+	} else if repo.Username != "" {
+		req.SetBasicAuth(repo.Username, repo.Password)
+	}
+}
+
+// Authorize sets the credentials of the repository serving req.URL on req.
+// req is left untouched if the URL is not under any of the Repositories.
+func Authorize(req *http.Request) {
+	u := req.URL.String()
+	for _, repo := range Repositories {
+		if strings.HasPrefix(u, repo.URL+"/") {
+			repo.authorize(req)
+			return
+		}
+	}
+}
+
+// Repositories are queried in order. A version found in multiple
+// repositories is attributed to the first one.
+var Repositories = []Repository{
+	{Id: "central", URL: CentralURL},
+}
+
+// settings.xml, as documented in https://maven.apache.org/settings.html
+type settingsXml struct {
+	Servers []struct {
+		Id       string `xml:"id"`
+		Username string `xml:"username"`
+		Password string `xml:"password"`
+		Headers  []struct {
+			Name  string `xml:"name"`
+			Value string `xml:"value"`
+		} `xml:"configuration>httpHeaders>property"`
+	} `xml:"servers>server"`
+	Mirrors []struct {
+		Id       string `xml:"id"`
+		MirrorOf string `xml:"mirrorOf"`
+		URL      string `xml:"url"`
+	} `xml:"mirrors>mirror"`
+	Profiles []struct {
+		Id              string `xml:"id"`
+		ActiveByDefault bool   `xml:"activation>activeByDefault"`
+		Repositories    []struct {
+			Id  string `xml:"id"`
+			URL string `xml:"url"`
+		} `xml:"repositories>repository"`
+	} `xml:"profiles>profile"`
+	ActiveProfiles []string `xml:"activeProfiles>activeProfile"`
+}
+
+var reEnvRef = regexp.MustCompile(`\$\{env\.(\w+)\}`)
+
+func expandEnv(s string) string {
+	return reEnvRef.ReplaceAllStringFunc(s, func(m string) string {
+		return os.Getenv(reEnvRef.FindStringSubmatch(m)[1])
+	})
+}
+
+func mirrorMatches(mirrorOf, repoId string) bool {
+	matched := false
+	for _, pat := range strings.Split(mirrorOf, ",") {
+		pat = strings.TrimSpace(pat)
+		switch {
+		case strings.HasPrefix(pat, "!"):
+			if pat[1:] == repoId {
+				return false
+			}
+		case pat == "*", pat == "external:*", pat == repoId:
+			matched = true
+		}
+	}
+	return matched
+}
+
+// ParseSettings reads the repositories from active profiles, the mirrors and
+// the server credentials in a Maven settings.xml. Maven Central is always
+// included as the last repository unless mirrored.
+func ParseSettings(bs []byte) ([]Repository, error) {
+	var s settingsXml
+	if err := xml.Unmarshal(bs, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse settings.xml: %w", err)
+	}
+
+	active := make(map[string]struct{})
+	for _, id := range s.ActiveProfiles {
+		active[id] = struct{}{}
+	}
+
+	repos := make([]Repository, 0)
+	for _, p := range s.Profiles {
+		if _, ok := active[p.Id]; !ok && !p.ActiveByDefault {
+			continue
+		}
+		for _, r := range p.Repositories {
+			repos = append(repos, Repository{Id: r.Id, URL: expandEnv(r.URL)})
+		}
+	}
+	repos = append(repos, Repository{Id: "central", URL: CentralURL})
+
+	seen := make(map[string]struct{})
+	mirrored := make([]Repository, 0, len(repos))
+	for _, r := range repos {
+		for _, m := range s.Mirrors {
+			if mirrorMatches(m.MirrorOf, r.Id) {
+				r = Repository{Id: m.Id, URL: expandEnv(m.URL)}
+				break
+			}
+		}
+		if _, ok := seen[r.Id]; ok {
+			continue
+		}
+		seen[r.Id] = struct{}{}
+		mirrored = append(mirrored, r)
+	}
+
+	for i := range mirrored {
+		r := &mirrored[i]
+		for _, srv := range s.Servers {
+			if srv.Id != r.Id {
+				continue
+			}
+			r.Username = expandEnv(srv.Username)
+			r.Password = expandEnv(srv.Password)
+			for _, h := range srv.Headers {
+				if strings.EqualFold(h.Name, "Authorization") {
+					r.Token = strings.TrimPrefix(expandEnv(h.Value), "Bearer ")
+				}
+			}
+		}
+		r.URL = strings.TrimRight(r.URL, "/")
+	}
+
+	return mirrored, nil
+}
+
+var reNonWord = regexp.MustCompile(`\W`)
+
+func envKey(repoId, name string) string {
+	id := strings.ToUpper(reNonWord.ReplaceAllString(repoId, "_"))
+	return fmt.Sprintf("LATEST_M2_%s_%s", id, name)
+}
+
+// ParseRepositoriesEnv parses a comma separated list of "id=url" pairs, as
+// specified in $LATEST_M2_REPOSITORIES. The credentials for each repository
+// are read from $LATEST_M2_{ID}_USERNAME, $LATEST_M2_{ID}_PASSWORD and
+// $LATEST_M2_{ID}_TOKEN.
+func ParseRepositoriesEnv(s string) ([]Repository, error) {
+	repos := make([]Repository, 0)
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+
+		ss := strings.SplitN(kv, "=", 2)
+		if len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+			return nil, fmt.Errorf("Failed to parse Maven repository %q. Expected \"id=url\".", kv)
+		}
+		id, url := ss[0], ss[1]
+
+		repos = append(repos, Repository{
+			Id:       id,
+			URL:      strings.TrimRight(url, "/"),
+			Username: os.Getenv(envKey(id, "USERNAME")),
+			Password: os.Getenv(envKey(id, "PASSWORD")),
+			Token:    os.Getenv(envKey(id, "TOKEN")),
+		})
+	}
+	return repos, nil
+}
+
+func DefaultSettingsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "settings.xml")
+}
+
+// Configure replaces Repositories with the ones specified in reposEnv (see
+// ParseRepositoriesEnv) if non-empty, otherwise with the ones in the
+// settings.xml at settingsPath. A missing settings.xml is not an error.
+func Configure(settingsPath, reposEnv string) error {
+	if reposEnv != "" {
+		repos, err := ParseRepositoriesEnv(reposEnv)
+		if err != nil {
+			return err
+		}
+		Repositories = repos
+		return nil
+	}
+
+	if settingsPath == "" {
+		return nil
+	}
+	bs, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("Failed to read Maven settings: %w", err)
+	}
+
+	repos, err := ParseSettings(bs)
+	if err != nil {
+		return err
+	}
+	Repositories = repos
+	return nil
+}
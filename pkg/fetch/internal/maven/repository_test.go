@@ -0,0 +1,108 @@
+package maven
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSettings(t *testing.T) {
+	os.Setenv("LATEST_TEST_NEXUS_PASSWORD", "s3cr3t")
+	defer os.Unsetenv("LATEST_TEST_NEXUS_PASSWORD")
+
+	settingsXml := `<settings>
+  <servers>
+    <server>
+      <id>internal</id>
+      <username>deployer</username>
+      <password>${env.LATEST_TEST_NEXUS_PASSWORD}</password>
+    </server>
+    <server>
+      <id>google-mirror</id>
+      <configuration>
+        <httpHeaders>
+          <property>
+            <name>Authorization</name>
+            <value>Bearer t0ken</value>
+          </property>
+        </httpHeaders>
+      </configuration>
+    </server>
+  </servers>
+  <mirrors>
+    <mirror>
+      <id>google-mirror</id>
+      <mirrorOf>central,!internal</mirrorOf>
+      <url>https://maven-central.storage-download.googleapis.com/maven2/</url>
+    </mirror>
+  </mirrors>
+  <profiles>
+    <profile>
+      <id>corp</id>
+      <repositories>
+        <repository>
+          <id>internal</id>
+          <url>https://artifactory.example/libs-release</url>
+        </repository>
+      </repositories>
+    </profile>
+    <profile>
+      <id>inactive</id>
+      <repositories>
+        <repository>
+          <id>unused</id>
+          <url>https://unused.example</url>
+        </repository>
+      </repositories>
+    </profile>
+  </profiles>
+  <activeProfiles>
+    <activeProfile>corp</activeProfile>
+  </activeProfiles>
+</settings>`
+
+	repos, err := ParseSettings([]byte(settingsXml))
+	if err != nil {
+		t.Fatalf("ParseSettings failed: %v", err)
+	}
+
+	expected := []Repository{
+		{
+			Id:       "internal",
+			URL:      "https://artifactory.example/libs-release",
+			Username: "deployer",
+			Password: "s3cr3t",
+		},
+		{
+			Id:    "google-mirror",
+			URL:   "https://maven-central.storage-download.googleapis.com/maven2",
+			Token: "t0ken",
+		},
+	}
+	if diffstr := cmp.Diff(repos, expected); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+}
+
+func TestParseRepositoriesEnv(t *testing.T) {
+	os.Setenv("LATEST_M2_MY_NEXUS_TOKEN", "t0ken")
+	defer os.Unsetenv("LATEST_M2_MY_NEXUS_TOKEN")
+
+	repos, err := ParseRepositoriesEnv("my-nexus=https://nexus.example/repository/maven/, central=https://repo1.maven.org/maven2")
+	if err != nil {
+		t.Fatalf("ParseRepositoriesEnv failed: %v", err)
+	}
+
+	expected := []Repository{
+		{Id: "my-nexus", URL: "https://nexus.example/repository/maven", Token: "t0ken"},
+		{Id: "central", URL: "https://repo1.maven.org/maven2"},
+	}
+	if diffstr := cmp.Diff(repos, expected); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+
+	if _, err := ParseRepositoriesEnv("bogus"); err == nil {
+		t.Errorf("Expected ParseRepositoriesEnv to fail on malformed input")
+	}
+}
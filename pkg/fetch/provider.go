@@ -0,0 +1,303 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/apache"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/chrome"
+	ferrors "github.com/IPA-CyberLab/latest/pkg/fetch/internal/errors"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/firefox"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/github"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/goruntime"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/hashicorp"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/linux"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/maven"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// ErrSoftwareIdParseFailed is returned by Provider.Fetch if the softwareId is
+// not of the provider after all, so that the next matching provider is tried.
+type ErrSoftwareIdParseFailed = ferrors.ErrSoftwareIdParseFailed
+
+// Capabilities describe what a Provider fills in the releases it fetches.
+type Capabilities struct {
+	Assets bool `json:"assets"`
+	// Checksums are set if the assets have Digests or ChecksumURLs.
+	Checksums  bool `json:"checksums"`
+	Signatures bool `json:"signatures"`
+	// Platforms are set if the assets have their OS and Arch.
+	Platforms   bool `json:"platforms"`
+	PublishedAt bool `json:"published_at"`
+	Notes       bool `json:"notes"`
+	Changelog   bool `json:"changelog"`
+}
+
+// Provider fetches the releases of the softwareIds it matches.
+type Provider interface {
+	Name() string
+	// Prefixes are the explicit softwareId prefixes, without the trailing
+	// ":", which route softwareIds to the provider without trying the
+	// others. The softwareId is passed to Fetch as is, prefix included.
+	Prefixes() []string
+	// Match reports if the provider may handle softwareId. It is cheap and
+	// must not do any I/O.
+	Match(softwareId string) bool
+	Fetch(ctx context.Context, softwareId string) (releases.Releases, error)
+	Capabilities() Capabilities
+}
+
+// BatchProvider is a Provider which can fetch many softwareIds at once more
+// efficiently than one by one.
+type BatchProvider interface {
+	Provider
+	// Batchable reports if softwareId may be passed to FetchMany.
+	Batchable(softwareId string) bool
+	FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error)
+}
+
+// Registry routes softwareIds to Providers.
+type Registry struct {
+	mu        sync.RWMutex
+	providers []Provider
+	prefixes  map[string]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{prefixes: make(map[string]Provider)}
+}
+
+// Register adds p to the registry. When guessing the provider of a
+// softwareId without an explicit prefix, providers are tried in the order
+// registered.
+func (r *Registry) Register(p Provider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, prefix := range p.Prefixes() {
+		if other, ok := r.prefixes[prefix]; ok {
+			return fmt.Errorf("Prefix %q of provider %q is already taken by provider %q", prefix, p.Name(), other.Name())
+		}
+	}
+	for _, prefix := range p.Prefixes() {
+		r.prefixes[prefix] = p
+	}
+	r.providers = append(r.providers, p)
+	return nil
+}
+
+func (r *Registry) Providers() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Provider(nil), r.providers...)
+}
+
+// explicit returns the provider of the explicit prefix of softwareId, if any.
+func (r *Registry) explicit(softwareId string) (Provider, bool) {
+	i := strings.IndexByte(softwareId, ':')
+	if i < 0 {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.prefixes[softwareId[:i]]
+	return p, ok
+}
+
+// candidates returns the providers which may handle softwareId in the order
+// to try.
+func (r *Registry) candidates(softwareId string) []Provider {
+	if p, ok := r.explicit(softwareId); ok {
+		return []Provider{p}
+	}
+
+	var ps []Provider
+	for _, p := range r.Providers() {
+		if p.Match(softwareId) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+func (r *Registry) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	for _, p := range r.candidates(softwareId) {
+		rs, err := p.Fetch(ctx, softwareId)
+		if err == nil {
+			return rs, nil
+		}
+
+		var parseErr ErrSoftwareIdParseFailed
+		if !errors.As(err, &parseErr) {
+			return nil, err
+		}
+		zap.S().Debugf("%s", err)
+	}
+
+	return nil, fmt.Errorf("No provider found for softwareId %q", softwareId)
+}
+
+// batchProviderOf returns the BatchProvider to fetch softwareId with, if
+// it would be the first one tried.
+func (r *Registry) batchProviderOf(softwareId string) (BatchProvider, bool) {
+	ps := r.candidates(softwareId)
+	if len(ps) == 0 {
+		return nil, false
+	}
+	bp, ok := ps[0].(BatchProvider)
+	if !ok || !bp.Batchable(softwareId) {
+		return nil, false
+	}
+	return bp, true
+}
+
+// FetchMany fetches the releases of all softwareIds, in batches where the
+// provider supports it, and one by one otherwise.
+func (r *Registry) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+
+	batches := make(map[BatchProvider][]string)
+	var batchProviders []BatchProvider
+	for _, softwareId := range softwareIds {
+		if bp, ok := r.batchProviderOf(softwareId); ok {
+			if _, ok := batches[bp]; !ok {
+				batchProviders = append(batchProviders, bp)
+			}
+			batches[bp] = append(batches[bp], softwareId)
+			continue
+		}
+
+		rs, err := r.Fetch(ctx, softwareId)
+		if err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		rss[softwareId] = rs
+	}
+
+	for _, bp := range batchProviders {
+		brss, berrs := bp.FetchMany(ctx, batches[bp])
+		for softwareId, rs := range brss {
+			rss[softwareId] = rs
+		}
+		for softwareId, err := range berrs {
+			errs[softwareId] = err
+		}
+	}
+	return rss, errs
+}
+
+// DefaultRegistry has the built-in providers registered. Providers registered
+// to it are tried after the built-in ones, unless routed by their prefixes.
+var DefaultRegistry = NewRegistry()
+
+// Register adds p to the DefaultRegistry.
+func Register(p Provider) error {
+	return DefaultRegistry.Register(p)
+}
+
+type builtinProvider struct {
+	name         string
+	prefixes     []string
+	capabilities Capabilities
+	match        func(softwareId string) bool
+	fetch        func(ctx context.Context, softwareId string) (releases.Releases, error)
+}
+
+func (p *builtinProvider) Name() string                 { return p.name }
+func (p *builtinProvider) Prefixes() []string           { return p.prefixes }
+func (p *builtinProvider) Match(softwareId string) bool { return p.match(softwareId) }
+func (p *builtinProvider) Capabilities() Capabilities   { return p.capabilities }
+
+func (p *builtinProvider) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	return p.fetch(ctx, softwareId)
+}
+
+type githubProvider struct {
+	builtinProvider
+}
+
+func (githubProvider) Batchable(softwareId string) bool {
+	return github.Batchable(softwareId)
+}
+
+func (githubProvider) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	return github.FetchMany(ctx, softwareIds)
+}
+
+var builtinProviders = []Provider{
+	&builtinProvider{
+		name:         hashicorp.HandlerName,
+		capabilities: Capabilities{Assets: true, Changelog: true},
+		match:        hashicorp.Match,
+		fetch:        hashicorp.Fetch,
+	},
+	&builtinProvider{
+		name:         goruntime.HandlerName,
+		prefixes:     []string{"go"},
+		capabilities: Capabilities{Assets: true, Checksums: true, Platforms: true, Changelog: true},
+		match:        goruntime.Match,
+		fetch:        goruntime.Fetch,
+	},
+	&builtinProvider{
+		name:         apache.HandlerName,
+		capabilities: Capabilities{Assets: true, Checksums: true, Signatures: true, PublishedAt: true},
+		match:        apache.Match,
+		fetch:        apache.Fetch,
+	},
+	&builtinProvider{
+		name:         maven.HandlerName,
+		prefixes:     []string{"m2"},
+		capabilities: Capabilities{Assets: true, Checksums: true, Signatures: true, PublishedAt: true},
+		match:        maven.Match,
+		fetch:        maven.Fetch,
+	},
+	&builtinProvider{
+		name:         chrome.HandlerName,
+		capabilities: Capabilities{},
+		match:        chrome.Match,
+		fetch:        chrome.Fetch,
+	},
+	&builtinProvider{
+		name:         chrome.ForTestingHandlerName,
+		capabilities: Capabilities{Assets: true, Platforms: true},
+		match:        chrome.MatchForTesting,
+		fetch:        chrome.FetchForTesting,
+	},
+	&builtinProvider{
+		name:         firefox.HandlerName,
+		capabilities: Capabilities{Assets: true, PublishedAt: true, Changelog: true},
+		match:        firefox.Match,
+		fetch:        firefox.Fetch,
+	},
+	&builtinProvider{
+		name:         linux.HandlerName,
+		capabilities: Capabilities{Assets: true, Signatures: true, PublishedAt: true, Changelog: true},
+		match:        linux.Match,
+		fetch:        linux.Fetch,
+	},
+	&githubProvider{builtinProvider{
+		name:         github.HandlerName,
+		prefixes:     []string{"github"},
+		capabilities: Capabilities{Assets: true, Checksums: true, PublishedAt: true, Notes: true, Changelog: true},
+		match:        github.Match,
+		fetch:        github.Fetch,
+	}},
+}
+
+func init() {
+	for _, p := range builtinProviders {
+		if err := DefaultRegistry.Register(p); err != nil {
+			panic(err)
+		}
+	}
+}
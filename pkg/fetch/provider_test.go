@@ -0,0 +1,215 @@
+package fetch_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+// fakeProvider claims softwareIds starting with "in-house/", but only knows
+// the ones in versions.
+type fakeProvider struct {
+	name     string
+	prefixes []string
+	versions map[string]string
+}
+
+func (p fakeProvider) Name() string                     { return p.name }
+func (p fakeProvider) Prefixes() []string               { return p.prefixes }
+func (p fakeProvider) Capabilities() fetch.Capabilities { return fetch.Capabilities{} }
+
+func (p fakeProvider) Match(softwareId string) bool {
+	return strings.HasPrefix(softwareId, "in-house/")
+}
+
+func (p fakeProvider) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	v, ok := p.versions[softwareId]
+	if !ok {
+		return nil, fetch.ErrSoftwareIdParseFailed{Input: softwareId, HandlerName: p.name}
+	}
+	return releases.Releases{{OriginalName: v, Version: semver.MustParse(v), Source: p.name}}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	reg := fetch.NewRegistry()
+	if err := reg.Register(fakeProvider{name: "a", prefixes: []string{"a"}, versions: map[string]string{
+		"in-house/foo": "1.0.0",
+		"a:bar":        "2.0.0",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(fakeProvider{name: "b", versions: map[string]string{
+		"in-house/foo": "3.0.0",
+		"in-house/baz": "4.0.0",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(fakeProvider{name: "c", prefixes: []string{"a"}}); err == nil {
+		t.Errorf("Expected an error registering a taken prefix")
+	}
+
+	testcases := []struct {
+		softwareId   string
+		expectSource string
+		expectErr    bool
+	}{
+		// Providers are tried in the order registered.
+		{"in-house/foo", "a", false},
+		// Falls through to the next matching provider.
+		{"in-house/baz", "b", false},
+		// Explicit prefixes skip Match.
+		{"a:bar", "a", false},
+		{"a:in-house/baz", "", true},
+		{"unknown", "", true},
+	}
+	for _, tc := range testcases {
+		rs, err := reg.Fetch(context.Background(), tc.softwareId)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("Fetch(%q): expected error, got %v", tc.softwareId, rs)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Fetch(%q): %v", tc.softwareId, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.expectSource, rs[0].Source); diff != "" {
+			t.Errorf("Fetch(%q) source mismatch (-want +got):\n%s", tc.softwareId, diff)
+		}
+	}
+}
+
+// fixtureTransport serves the bodies keyed by the request URLs, and 404s the
+// others.
+type fixtureTransport map[string]string
+
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, ok := t[req.URL.String()]
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+	if !ok {
+		resp.StatusCode, resp.Status = http.StatusNotFound, "404 Not Found"
+	}
+	return resp, nil
+}
+
+// capabilitiesFilled returns the Capabilities which rs actually make use of.
+func capabilitiesFilled(rs releases.Releases) fetch.Capabilities {
+	var cs fetch.Capabilities
+	for _, r := range rs {
+		cs.PublishedAt = cs.PublishedAt || !r.PublishedAt.IsZero()
+		cs.Notes = cs.Notes || r.Notes != ""
+		cs.Changelog = cs.Changelog || r.ChangelogURL != ""
+		for _, a := range r.Assets {
+			cs.Assets = true
+			cs.Checksums = cs.Checksums || len(a.Digests) > 0 || len(a.ChecksumURLs) > 0
+			cs.Signatures = cs.Signatures || a.SignatureURL != ""
+			cs.Platforms = cs.Platforms || a.OS != "" || a.Arch != ""
+		}
+	}
+	return cs
+}
+
+func TestBuiltinCapabilities(t *testing.T) {
+	origTransport := httpcli.HttpClient.Transport
+	defer func() { httpcli.HttpClient.Transport = origTransport }()
+
+	testcases := []struct {
+		provider   string
+		softwareId string
+		fixtures   fixtureTransport
+	}{
+		{"hashicorp", "consul", fixtureTransport{
+			"https://releases.hashicorp.com/consul/": `<a href="/consul/1.18.0/">consul_1.18.0</a>`,
+		}},
+		{"goruntime", "go", fixtureTransport{
+			"https://golang.org/dl/?mode=json&include=all": `[{"version": "go1.22.1", "stable": true, "files": [
+			  {"filename": "go1.22.1.linux-amd64.tar.gz", "os": "linux", "arch": "amd64", "sha256": "aab8e15785c997ae20f9c88422ee35d962c4562212bb0f879d052a35c8307c7f", "size": 68965341, "kind": "archive"}
+			]}]`,
+		}},
+		{"apache", "apache/httpd", fixtureTransport{
+			"https://projects.apache.org/json/foundation/releases.json": `{"httpd": {"httpd-2.4.58": "2023-10-19"}}`,
+			"https://archive.apache.org/dist/httpd/": `<a href="httpd-2.4.58.tar.gz">httpd-2.4.58.tar.gz</a>
+			  <a href="httpd-2.4.58.tar.gz.asc">httpd-2.4.58.tar.gz.asc</a>
+			  <a href="httpd-2.4.58.tar.gz.sha256">httpd-2.4.58.tar.gz.sha256</a>`,
+		}},
+		{"maven", "m2:org.example:lib", fixtureTransport{
+			"https://repo1.maven.org/maven2/org/example/lib/maven-metadata.xml": `<metadata><versioning>
+			  <latest>1.1</latest><release>1.1</release>
+			  <versions><version>1.0</version><version>1.1</version></versions>
+			  <lastUpdated>20240102030405</lastUpdated>
+			</versioning></metadata>`,
+		}},
+		{"chrome", "chrome", fixtureTransport{
+			"https://versionhistory.googleapis.com/v1/chrome/platforms/all/channels/stable/versions?pageSize=1000": `{"versions": [
+			  {"name": "chrome/platforms/win/channels/stable/versions/122.0.6261.94", "version": "122.0.6261.94"}
+			]}`,
+		}},
+		{"chrome-for-testing", "chrome-for-testing", fixtureTransport{
+			"https://googlechromelabs.github.io/chrome-for-testing/known-good-versions-with-downloads.json": `{"versions": [
+			  {"version": "122.0.6261.94", "revision": "1250580", "downloads": {"chrome": [
+			    {"platform": "linux64", "url": "https://storage.googleapis.com/chrome-for-testing-public/122.0.6261.94/linux64/chrome-linux64.zip"}
+			  ]}}
+			]}`,
+		}},
+		{"firefox", "firefox", fixtureTransport{
+			"https://product-details.mozilla.org/1.0/firefox.json": `{"releases": {
+			  "firefox-123.0": {"category": "major", "version": "123.0", "date": "2024-02-20"}
+			}}`,
+		}},
+		{"linux", "linux", fixtureTransport{
+			"https://www.kernel.org/releases.json": `{"releases": [{
+			  "version": "6.7.9", "moniker": "stable",
+			  "source": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.7.9.tar.xz",
+			  "pgp": "https://cdn.kernel.org/pub/linux/kernel/v6.x/linux-6.7.9.tar.sign",
+			  "released": {"timestamp": 1709807400},
+			  "changelog": "https://cdn.kernel.org/pub/linux/kernel/v6.x/ChangeLog-6.7.9"
+			}]}`,
+		}},
+		{"github", "github:cli/cli", fixtureTransport{
+			"https://api.github.com/repos/cli/cli/releases?per_page=100": `[{
+			  "tag_name": "v2.45.0", "body": "## What's Changed",
+			  "published_at": "2024-03-04T12:00:00Z", "html_url": "https://github.com/cli/cli/releases/tag/v2.45.0",
+			  "assets": [{"name": "gh_2.45.0_linux_amd64.tar.gz", "browser_download_url": "https://github.com/cli/cli/releases/download/v2.45.0/gh_2.45.0_linux_amd64.tar.gz",
+			    "digest": "sha256:1e3e1c1c3b8a3ac8cbd0b8fc3ae4f0a3c2a8fcd1d7f5c3b3d9f1c1f0c2e0d1a9"}]
+			}]`,
+		}},
+	}
+	for _, tc := range testcases {
+		var p fetch.Provider
+		for _, rp := range fetch.DefaultRegistry.Providers() {
+			if rp.Name() == tc.provider {
+				p = rp
+			}
+		}
+		if p == nil {
+			t.Errorf("Provider %q is not registered", tc.provider)
+			continue
+		}
+
+		httpcli.HttpClient.Transport = tc.fixtures
+		rs, err := p.Fetch(context.Background(), tc.softwareId)
+		if err != nil {
+			t.Errorf("%s: Fetch(%q): %v", tc.provider, tc.softwareId, err)
+			continue
+		}
+		if diff := cmp.Diff(p.Capabilities(), capabilitiesFilled(rs)); diff != "" {
+			t.Errorf("%s: declared capabilities mismatch what Fetch(%q) fills (-declared +filled):\n%s", tc.provider, tc.softwareId, diff)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+const SnapshotFormatVersion = 1
+
+// Snapshot is a self-contained bundle of the releases of the softwareIds
+// referred to by a set of queries. All releases of the softwareIds are kept,
+// so that the queries, or any other query of the same softwareIds, resolve
+// the same without network access.
+type Snapshot struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Queries the snapshot was created from, for reference.
+	Queries  []string                     `json:"queries"`
+	Releases map[string]releases.Releases `json:"releases"`
+}
+
+// CreateSnapshot fetches the releases of the softwareIds of queries from
+// backend, all at once if it is a BatchBackend. It fails if any of them
+// fails, as an incomplete snapshot would resolve differently.
+func CreateSnapshot(ctx context.Context, backend Backend, queries []string) (*Snapshot, error) {
+	var softwareIds []string
+	seen := make(map[string]struct{})
+	for _, s := range queries {
+		q, err := parser.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse query %q: %w", s, err)
+		}
+		if _, ok := seen[q.SoftwareId]; ok {
+			continue
+		}
+		seen[q.SoftwareId] = struct{}{}
+		softwareIds = append(softwareIds, q.SoftwareId)
+	}
+
+	rss, errs := fetchMany(ctx, backend, softwareIds)
+
+	var msgs []string
+	for _, softwareId := range softwareIds {
+		if err := errs[softwareId]; err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", softwareId, err))
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, fmt.Errorf("Failed to fetch %d of %d softwareIds: %s", len(msgs), len(softwareIds), strings.Join(msgs, "; "))
+	}
+
+	s := &Snapshot{
+		FormatVersion: SnapshotFormatVersion,
+		CreatedAt:     NowImpl().UTC(),
+		Queries:       queries,
+		Releases:      make(map[string]releases.Releases, len(softwareIds)),
+	}
+	for _, softwareId := range softwareIds {
+		rs := rss[softwareId]
+		if rs == nil {
+			rs = releases.Releases{}
+		}
+		s.Releases[softwareId] = rs
+	}
+	return s, nil
+}
+
+// SoftwareIds returns the softwareIds in the snapshot, sorted.
+func (s *Snapshot) SoftwareIds() []string {
+	ids := make([]string, 0, len(s.Releases))
+	for softwareId := range s.Releases {
+		ids = append(ids, softwareId)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ReadSnapshot reads the snapshot written by WriteFile.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read snapshot: %w", err)
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(bs, s); err != nil {
+		return nil, fmt.Errorf("Failed to parse snapshot %q: %w", path, err)
+	}
+	if s.FormatVersion != SnapshotFormatVersion {
+		return nil, fmt.Errorf("Unsupported format version %d of snapshot %q", s.FormatVersion, path)
+	}
+	if s.Releases == nil {
+		s.Releases = make(map[string]releases.Releases)
+	}
+	return s, nil
+}
+
+// WriteFile writes the snapshot in indented JSON, atomically replacing path.
+func (s *Snapshot) WriteFile(path string) error {
+	bs, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	bs = append(bs, '\n')
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("Failed to write snapshot: %w", err)
+	}
+	tmpPath := f.Name()
+	if _, err := f.Write(bs); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("Failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// ParseQueries reads a query per line. Blank lines and lines starting with
+// "#" are ignored.
+func ParseQueries(bs []byte) []string {
+	var queries []string
+	sc := bufio.NewScanner(bytes.NewReader(bs))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	return queries
+}
+
+// snapshotFetcher serves the softwareIds in the snapshot, and fetches the
+// rest from backend unless it is nil.
+type snapshotFetcher struct {
+	snapshot *Snapshot
+	backend  Backend
+}
+
+// NewSnapshotFetcher returns a BatchBackend serving the softwareIds in
+// snapshot, and fetching the rest from backend. If backend is nil, fetching
+// them fails as in offline mode.
+func NewSnapshotFetcher(snapshot *Snapshot, backend Backend) BatchBackend {
+	return snapshotFetcher{snapshot: snapshot, backend: backend}
+}
+
+func errNotInSnapshot(softwareId string) error {
+	return fmt.Errorf("%q is not in the snapshot, and cannot be fetched in offline mode", softwareId)
+}
+
+func (f snapshotFetcher) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	if rs, ok := f.snapshot.Releases[softwareId]; ok {
+		return rs, nil
+	}
+	if f.backend == nil {
+		return nil, errNotInSnapshot(softwareId)
+	}
+	return f.backend.Fetch(ctx, softwareId)
+}
+
+// FetchMany serves the softwareIds in the snapshot, and fetches the rest from
+// backend, all at once if it is a BatchBackend.
+func (f snapshotFetcher) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+
+	var misses []string
+	for _, softwareId := range softwareIds {
+		if rs, ok := f.snapshot.Releases[softwareId]; ok {
+			rss[softwareId] = rs
+			continue
+		}
+		if f.backend == nil {
+			errs[softwareId] = errNotInSnapshot(softwareId)
+			continue
+		}
+		misses = append(misses, softwareId)
+	}
+	if len(misses) == 0 {
+		return rss, errs
+	}
+
+	fetched, fetchErrs := fetchMany(ctx, f.backend, misses)
+	for _, softwareId := range misses {
+		if err := fetchErrs[softwareId]; err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		rss[softwareId] = fetched[softwareId]
+	}
+	return rss, errs
+}
+
+type OfflineConfig struct {
+	// Offline refuses all HTTP requests of the providers and downloads.
+	Offline bool
+	// SnapshotPath, if set, is a snapshot serving the softwareIds in it.
+	// The others are fetched as usual unless Offline.
+	SnapshotPath string
+}
+
+var offlineConfig OfflineConfig
+var loadedSnapshot *Snapshot
+
+// ConfigureOffline loads the snapshot and makes NewFetcher serve from it.
+func ConfigureOffline(cfg OfflineConfig) error {
+	httpcli.Offline = cfg.Offline
+	offlineConfig = cfg
+	loadedSnapshot = nil
+
+	if cfg.SnapshotPath == "" {
+		return nil
+	}
+	s, err := ReadSnapshot(cfg.SnapshotPath)
+	if err != nil {
+		return err
+	}
+	zap.S().Debugf("Loaded snapshot of %d softwareIds created at %v", len(s.Releases), s.CreatedAt)
+	loadedSnapshot = s
+	return nil
+}
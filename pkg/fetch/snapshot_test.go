@@ -0,0 +1,133 @@
+package fetch_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch"
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+func TestParseQueries(t *testing.T) {
+	qs := fetch.ParseQueries([]byte(`
+# toolchain
+go@1.16
+  github.com/IPA-CyberLab/latest:prerelease
+
+`))
+	if diff := cmp.Diff([]string{"go@1.16", "github.com/IPA-CyberLab/latest:prerelease"}, qs); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b := &countingBackend{fetches: map[string]int{}}
+	s, err := fetch.CreateSnapshot(context.Background(), b, []string{"foo@1", "foo:prerelease", "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"bar", "foo"}, s.SoftwareIds()); diff != "" {
+		t.Errorf("SoftwareIds (-want +got):\n%s", diff)
+	}
+	if b.fetches["foo"] != 1 {
+		t.Errorf("foo fetched %d times", b.fetches["foo"])
+	}
+	if _, err := fetch.CreateSnapshot(context.Background(), b, []string{"foo", "broken"}); err == nil {
+		t.Errorf("expected error on a failed fetch")
+	}
+
+	path := filepath.Join(dir, "bundle.json")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fetch.ConfigureOffline(fetch.OfflineConfig{Offline: true, SnapshotPath: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fetch.ConfigureOffline(fetch.OfflineConfig{}) }()
+
+	q, err := parser.Parse("foo@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := q.Execute(context.Background(), fetch.NewFetcher())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(s.Releases["foo"], rs); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+
+	if _, err := fetch.NewFetcher().Fetch(context.Background(), "go"); err == nil {
+		t.Errorf("expected error on a softwareId not in the snapshot")
+	}
+}
+
+// batchBackend is a countingBackend recording the softwareIds fetched at
+// once by FetchMany.
+type batchBackend struct {
+	countingBackend
+	batches [][]string
+}
+
+func (b *batchBackend) FetchMany(ctx context.Context, softwareIds []string) (map[string]releases.Releases, map[string]error) {
+	b.batches = append(b.batches, softwareIds)
+	rss := make(map[string]releases.Releases)
+	errs := make(map[string]error)
+	for _, softwareId := range softwareIds {
+		rs, err := b.Fetch(ctx, softwareId)
+		if err != nil {
+			errs[softwareId] = err
+			continue
+		}
+		rss[softwareId] = rs
+	}
+	return rss, errs
+}
+
+func TestSnapshotFetcherFetchMany(t *testing.T) {
+	s := &fetch.Snapshot{Releases: map[string]releases.Releases{
+		"foo": {{OriginalName: "0.9.0", Version: semver.MustParse("0.9.0")}},
+	}}
+	versionsOf := func(rss map[string]releases.Releases) map[string]string {
+		vs := make(map[string]string)
+		for softwareId, rs := range rss {
+			vs[softwareId] = rs[0].OriginalName
+		}
+		return vs
+	}
+
+	b := &batchBackend{countingBackend: countingBackend{fetches: map[string]int{}}}
+	rss, errs := fetch.NewSnapshotFetcher(s, b).FetchMany(context.Background(), []string{"foo", "bar", "broken"})
+	if diff := cmp.Diff(map[string]string{"foo": "0.9.0", "bar": "1.0.1"}, versionsOf(rss)); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+	if len(errs) != 1 || errs["broken"] == nil {
+		t.Errorf("Unexpected errs: %v", errs)
+	}
+	if diff := cmp.Diff([][]string{{"bar", "broken"}}, b.batches); diff != "" {
+		t.Errorf("Only the misses should be fetched at once (-want +got):\n%s", diff)
+	}
+
+	// Offline
+	rss, errs = fetch.NewSnapshotFetcher(s, nil).FetchMany(context.Background(), []string{"foo", "bar"})
+	if diff := cmp.Diff(map[string]string{"foo": "0.9.0"}, versionsOf(rss)); diff != "" {
+		t.Errorf("offline (-want +got):\n%s", diff)
+	}
+	if len(errs) != 1 || errs["bar"] == nil {
+		t.Errorf("Unexpected offline errs: %v", errs)
+	}
+}
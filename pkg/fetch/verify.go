@@ -0,0 +1,147 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/httpcli"
+	"github.com/IPA-CyberLab/latest/pkg/fetch/internal/maven"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+var ErrNoChecksum = errors.New("No checksum available for the asset.")
+
+// ErrChecksumMismatch is returned by VerifyAsset if the downloaded asset does
+// not match its published checksum.
+type ErrChecksumMismatch struct {
+	URL       string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for %s: expected %s, got %s", e.Algorithm, e.URL, e.Expected, e.Actual)
+}
+
+// Strongest first.
+var verifyAlgorithms = []struct {
+	name    string
+	newHash func() hash.Hash
+}{
+	{"sha512", sha512.New},
+	{"sha256", sha256.New},
+	{"sha1", sha1.New},
+}
+
+var downloadClient = httpcli.DownloadClient
+
+var errNotFound = errors.New("not found")
+
+func openURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to construct http.Request: %w", err)
+	}
+	maven.Authorize(req)
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to issue request to %s: %w", url, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func fetchChecksum(ctx context.Context, url string) (string, error) {
+	body, err := openURL(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	bs, err := ioutil.ReadAll(io.LimitReader(body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("Failed to read body of %s: %w", url, err)
+	}
+
+	// Checksum files may be in the "sha256sum" format: "<digest>  <filename>"
+	fields := strings.Fields(string(bs))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("Empty checksum file %s", url)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// VerifyAsset downloads the asset at assetURL of r, and checks it against the
+// strongest digest published for it, either along with the release or as a
+// checksum file. It returns the name of the digest algorithm used for
+// verification.
+func VerifyAsset(ctx context.Context, r releases.Release, assetURL string) (string, error) {
+	l := zap.S()
+
+	asset, ok := r.Asset(assetURL)
+	if !ok || (len(asset.Digests) == 0 && len(asset.ChecksumURLs) == 0) {
+		return "", ErrNoChecksum
+	}
+
+	for _, algo := range verifyAlgorithms {
+		expected, ok := asset.Digests[algo.name]
+		if ok {
+			expected = strings.ToLower(expected)
+		} else {
+			checksumURL, ok := asset.ChecksumURLs[algo.name]
+			if !ok {
+				continue
+			}
+
+			var err error
+			expected, err = fetchChecksum(ctx, checksumURL)
+			if err != nil {
+				if errors.Is(err, errNotFound) {
+					l.Debugf("%s checksum not published at %s", algo.name, checksumURL)
+					continue
+				}
+				return "", err
+			}
+		}
+
+		body, err := openURL(ctx, assetURL)
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+
+		h := algo.newHash()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", fmt.Errorf("Failed to download %s: %w", assetURL, err)
+		}
+
+		actual := hex.EncodeToString(h.Sum(nil))
+		if actual != expected {
+			return "", ErrChecksumMismatch{URL: assetURL, Algorithm: algo.name, Expected: expected, Actual: actual}
+		}
+		return algo.name, nil
+	}
+
+	return "", ErrNoChecksum
+}
@@ -0,0 +1,65 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+func TestVerifyAsset(t *testing.T) {
+	const content = "hello\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lib-1.0.jar", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(content))
+	})
+	mux.HandleFunc("/lib-1.0.jar.sha256", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  lib-1.0.jar\n"))
+	})
+	mux.HandleFunc("/lib-1.0.jar.sha1", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("0000000000000000000000000000000000000000"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assetURL := srv.URL + "/lib-1.0.jar"
+	r := releases.Release{
+		Assets: []releases.Asset{{
+			URL: assetURL,
+			ChecksumURLs: map[string]string{
+				"sha1":   assetURL + ".sha1",
+				"sha256": assetURL + ".sha256",
+				"sha512": assetURL + ".sha512", // 404
+			},
+		}},
+	}
+
+	algo, err := VerifyAsset(context.Background(), r, assetURL)
+	if err != nil {
+		t.Fatalf("VerifyAsset failed: %v", err)
+	}
+	if algo != "sha256" {
+		t.Errorf("Expected verification with sha256, got %s", algo)
+	}
+
+	delete(r.Assets[0].ChecksumURLs, "sha256")
+	_, err = VerifyAsset(context.Background(), r, assetURL)
+	var mismatch ErrChecksumMismatch
+	if !errors.As(err, &mismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	// A digest published along with the release takes precedence over the
+	// checksum files of weaker algorithms.
+	r.Assets[0].Digests = map[string]string{"sha256": "5891B5B522D5DF086D0FF0B110FBD9D21BB4FC7163AF34D08286A2E846F6BE03"}
+	if algo, err := VerifyAsset(context.Background(), r, assetURL); err != nil || algo != "sha256" {
+		t.Errorf("Expected verification with the sha256 digest, got %s, %v", algo, err)
+	}
+
+	if _, err := VerifyAsset(context.Background(), releases.Release{}, assetURL); !errors.Is(err, ErrNoChecksum) {
+		t.Errorf("Expected ErrNoChecksum, got %v", err)
+	}
+}
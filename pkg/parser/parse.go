@@ -10,6 +10,7 @@ import (
 	"github.com/blang/semver/v4"
 
 	"github.com/IPA-CyberLab/latest/pkg/query"
+	"github.com/IPA-CyberLab/latest/pkg/scheme"
 )
 
 var reComponentAndVersion = regexp.MustCompile(`^([A-z_\-]*)(\d+(\..*)?)$`)
@@ -23,6 +24,13 @@ func ParseComponentAndVersion(s string) (string, semver.Version, error) {
 	component, verStr := ms[1], ms[2]
 	component = strings.TrimRight(component, "_-")
 
+	// Components past the patch version are not representable in semver,
+	// and are returned by ParseComponents instead, e.g. "1.2.3.4-beta" ->
+	// "1.2.3-beta".
+	if ms := reExtraComponents.FindStringSubmatch(verStr); len(ms) != 0 {
+		verStr = ms[1] + ms[3]
+	}
+
 	ver, err := semver.ParseTolerant(verStr)
 	if err != nil {
 		return "", semver.Version{}, fmt.Errorf("Failed to parse version %q", verStr)
@@ -36,16 +44,63 @@ func ParseVersion(s string) (semver.Version, error) {
 	return ver, err
 }
 
+var reExtraComponents = regexp.MustCompile(`^(v?\d+\.\d+\.\d+)((?:\.\d+)+)(.*)$`)
+var reLeadingComponents = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+// ParseComponents returns all the numeric components of the version in s,
+// including the ones past the patch version which ParseVersion drops, e.g.
+// [1 2 3 4] for "v1.2.3.4-beta", to be set as releases.Release.Components.
+// It returns nil if s is not a version or there are no such components.
+func ParseComponents(s string) []uint64 {
+	ms := reComponentAndVersion.FindStringSubmatch(s)
+	if len(ms) == 0 {
+		return nil
+	}
+	var cs []uint64
+	for _, c := range strings.Split(reLeadingComponents.FindString(ms[2]), ".") {
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			return nil
+		}
+		cs = append(cs, n)
+	}
+	if len(cs) <= 3 {
+		return nil
+	}
+	return cs
+}
+
 type queryIntermediate struct {
-	SoftwareId  string
-	VerRangeStr string
-	Prerelease  bool
+	SoftwareId   string
+	VerRangeStr  string
+	Prerelease   bool
+	Scheme       string
+	AssetFilters []string
+}
+
+// Aliases maps a name to the query it stands for. Attributes following the
+// name are appended to the query, e.g. "terraform:prerelease".
+var Aliases = map[string]string{}
+
+// ExpandAlias returns s with the leading alias name replaced by its query.
+// Aliases are not expanded recursively.
+func ExpandAlias(s string) string {
+	ms := reSoftwareIdAndRest.FindStringSubmatch(s)
+	if len(ms) == 0 {
+		return s
+	}
+	if q, ok := Aliases[ms[1]]; ok {
+		return q + ms[2]
+	}
+	return s
 }
 
 var reSoftwareIdAndRest = regexp.MustCompile(`^([^@<>=:]*)(.*)$`)
 var reAtVersion = regexp.MustCompile(`^@v?(\d+)(\.(\d+))?(\.(\d+))?(.*)$`)
 var reRangeVersion = regexp.MustCompile(`^([<>]=?[\d\.]+)(.*)$`)
-var reFlag = regexp.MustCompile(`^:([^@<>=:]*)(.*)$`)
+
+// Flags are either ":name" or ":name=value".
+var reFlag = regexp.MustCompile(`^:([^@<>=:]*(?:=[^@<>:]*)?)(.*)$`)
 
 func parseInternal(s string) (*queryIntermediate, error) {
 	ms := reSoftwareIdAndRest.FindStringSubmatch(s)
@@ -98,9 +153,18 @@ func parseInternal(s string) (*queryIntermediate, error) {
 		if len(ms) != 0 {
 			flag := ms[1]
 
-			switch flag {
-			case "prerelease":
+			switch {
+			case flag == "prerelease":
 				qi.Prerelease = true
+			case strings.HasPrefix(flag, "scheme="):
+				qi.Scheme = strings.TrimPrefix(flag, "scheme=")
+			case strings.HasPrefix(flag, "assetFilter="):
+				// Asset URLs are matched in lower case.
+				needle := strings.ToLower(strings.TrimPrefix(flag, "assetFilter="))
+				if needle == "" || needle == "!" {
+					return nil, fmt.Errorf("Empty assetFilter in %q", s)
+				}
+				qi.AssetFilters = append(qi.AssetFilters, needle)
 			default:
 				qi.SoftwareId = fmt.Sprintf("%s:%s", qi.SoftwareId, flag)
 			}
@@ -116,13 +180,13 @@ func parseInternal(s string) (*queryIntermediate, error) {
 }
 
 func Parse(s string) (*query.Query, error) {
-	qi, err := parseInternal(s)
+	qi, err := parseInternal(ExpandAlias(s))
 	if err != nil {
 		return nil, err
 	}
 
 	var vr semver.Range
-	if qi.VerRangeStr != "" {
+	if qi.VerRangeStr != "" && qi.Scheme == "" {
 		vr, err = semver.ParseRange(qi.VerRangeStr)
 		if err != nil {
 			return nil, err
@@ -130,9 +194,25 @@ func Parse(s string) (*query.Query, error) {
 	}
 
 	q := &query.Query{
-		SoftwareId: qi.SoftwareId,
-		VerRange:   vr,
-		Prerelease: qi.Prerelease,
+		SoftwareId:   qi.SoftwareId,
+		VerRange:     vr,
+		Prerelease:   qi.Prerelease,
+		AssetFilters: qi.AssetFilters,
+	}
+	if qi.Scheme != "" {
+		q.Scheme, err = scheme.Get(qi.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		q.SchemeRange, err = scheme.ParseRange(qi.VerRangeStr)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range q.SchemeRange {
+			if _, err := q.Scheme.Parse(c.Version); err != nil {
+				return nil, fmt.Errorf("Failed to parse version range %q: %w", qi.VerRangeStr, err)
+			}
+		}
 	}
 	return q, nil
 }
@@ -9,14 +9,17 @@ import (
 
 func TestParseComponentAndVersion(t *testing.T) {
 	tcs := []struct {
-		input     string
-		component string
-		verstr    string
+		input      string
+		component  string
+		verstr     string
+		components []uint64
 	}{
-		{"apache-wicket-8.11.0", "apache-wicket", "8.11.0"},
-		{"go1.15", "go", "1.15"},
-		{"SparkR_3.0.1", "SparkR", "3.0.1"},
-		{"352", "", "352"},
+		{"apache-wicket-8.11.0", "apache-wicket", "8.11.0", nil},
+		{"go1.15", "go", "1.15", nil},
+		{"SparkR_3.0.1", "SparkR", "3.0.1", nil},
+		{"352", "", "352", nil},
+		{"idea-2024.1.2.3", "idea", "2024.1.2", []uint64{2024, 1, 2, 3}},
+		{"v1.2.3.4-beta", "v", "1.2.3-beta", []uint64{1, 2, 3, 4}},
 	}
 
 	for _, tc := range tcs {
@@ -36,9 +39,13 @@ func TestParseComponentAndVersion(t *testing.T) {
 			continue
 		}
 
-		if !verExpected.Equals(ver) {
+		if verExpected.String() != ver.String() {
 			t.Errorf("%q: Expected ver %v, got %v", tc.input, verExpected, ver)
 		}
+
+		if diff := cmp.Diff(tc.components, ParseComponents(tc.input)); diff != "" {
+			t.Errorf("%q: components (-want +got):\n%s", tc.input, diff)
+		}
 	}
 }
 
@@ -77,6 +84,25 @@ func TestParse(t *testing.T) {
 			VerRangeStr: ">=4.0.0 <5.0.0 ",
 			Prerelease:  true,
 		}},
+		{"github.com/aws/aws-sdk-go-v2:prefix=service/s3/@v1:prerelease", queryIntermediate{
+			SoftwareId:  "github.com/aws/aws-sdk-go-v2:prefix=service/s3/",
+			VerRangeStr: ">=1.0.0 <2.0.0 ",
+			Prerelease:  true,
+		}},
+		{"github.com/ubuntu/ubuntu@24:scheme=calver", queryIntermediate{
+			SoftwareId:  "github.com/ubuntu/ubuntu",
+			VerRangeStr: ">=24.0.0 <25.0.0 ",
+			Scheme:      "calver",
+		}},
+		{"github.com/hashicorp/terraform@1:assetFilter=linux:assetFilter=!arm", queryIntermediate{
+			SoftwareId:   "github.com/hashicorp/terraform",
+			VerRangeStr:  ">=1.0.0 <2.0.0 ",
+			AssetFilters: []string{"linux", "!arm"},
+		}},
+		{"github.com/hashicorp/terraform:assetFilter=Linux:assetFilter=!ARM64", queryIntermediate{
+			SoftwareId:   "github.com/hashicorp/terraform",
+			AssetFilters: []string{"linux", "!arm64"},
+		}},
 		{"m2:io.trino:trino-server:prerelease", queryIntermediate{
 			SoftwareId:  "m2:io.trino:trino-server",
 			VerRangeStr: "",
@@ -101,3 +127,37 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestExpandAlias(t *testing.T) {
+	Aliases["terraform"] = "github.com/hashicorp/terraform@1:assetFilter=linux"
+	Aliases["tf"] = "terraform"
+	defer func() {
+		delete(Aliases, "terraform")
+		delete(Aliases, "tf")
+	}()
+
+	tcs := []struct {
+		input  string
+		expect string
+	}{
+		{"terraform", "github.com/hashicorp/terraform@1:assetFilter=linux"},
+		{"terraform:prerelease", "github.com/hashicorp/terraform@1:assetFilter=linux:prerelease"},
+		// Not expanded recursively.
+		{"tf", "terraform"},
+		{"github.com/hashicorp/terraform", "github.com/hashicorp/terraform"},
+		{"terraform-provider", "terraform-provider"},
+	}
+	for _, tc := range tcs {
+		if actual := ExpandAlias(tc.input); actual != tc.expect {
+			t.Errorf("ExpandAlias(%q): expected %q, got %q", tc.input, tc.expect, actual)
+		}
+	}
+
+	q, err := Parse("terraform:assetFilter=amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"linux", "amd64"}, q.AssetFilters); q.SoftwareId != "github.com/hashicorp/terraform" || diff != "" {
+		t.Errorf("Unexpected query: %+v", q)
+	}
+}
@@ -2,33 +2,111 @@ package query
 
 import (
 	"context"
+	"errors"
 
 	"github.com/blang/semver/v4"
 
 	"github.com/IPA-CyberLab/latest/pkg/releases"
+	"github.com/IPA-CyberLab/latest/pkg/scheme"
 )
 
 type Query struct {
 	SoftwareId string
 	VerRange   semver.Range
 	Prerelease bool
+	// Scheme overrides how the provider parsed and ordered the versions, if
+	// non-nil. SchemeRange is then matched in it instead of VerRange.
+	Scheme      scheme.Scheme
+	SchemeRange scheme.Range
+	// AssetFilters narrow down the assets of the releases, as
+	// releases.Release.FilterAssets does.
+	AssetFilters []string
 }
 
 type Fetcher interface {
 	Fetch(ctx context.Context, softwareId string) (rs releases.Releases, err error)
 }
 
-func (q *Query) Execute(ctx context.Context, fetcher Fetcher) (releases.Releases, error) {
+// Prefetcher is implemented by Fetchers which can fetch many softwareIds more
+// efficiently at once. Prefetch starts fetching softwareIds expected to be
+// Fetch-ed soon.
+type Prefetcher interface {
+	Prefetch(ctx context.Context, softwareIds []string)
+}
+
+// ErrNoRelease is returned by Changelog when no release matched the query.
+var ErrNoRelease = errors.New("No release matched.")
+
+// candidates fetches the releases and applies the flags of the query other
+// than the version range and asset filters.
+func (q *Query) candidates(ctx context.Context, fetcher Fetcher) (releases.Releases, error) {
 	rs, err := fetcher.Fetch(ctx, q.SoftwareId)
 	if err != nil {
 		return nil, err
 	}
 
-	rs = rs.SelectAll(q.VerRange)
+	if q.Scheme != nil {
+		rs = rs.WithScheme(q.Scheme)
+	} else {
+		rs = rs.RemoveUnparsed()
+	}
 
 	if !q.Prerelease {
 		rs = rs.RemovePrerelease()
 	}
+	return rs, nil
+}
+
+// selectAll returns the releases in the version range of the query.
+func (q *Query) selectAll(rs releases.Releases) releases.Releases {
+	if q.Scheme != nil {
+		return rs.SelectAllInScheme(q.Scheme, q.SchemeRange)
+	}
+	return rs.SelectAll(q.VerRange)
+}
+
+func (q *Query) Execute(ctx context.Context, fetcher Fetcher) (releases.Releases, error) {
+	rs, err := q.candidates(ctx, fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	rs = q.selectAll(rs)
+
+	if len(q.AssetFilters) > 0 {
+		for i := range rs {
+			for _, f := range q.AssetFilters {
+				rs[i].FilterAssets(f)
+			}
+		}
+	}
 
 	return rs, nil
 }
+
+// Changelog returns the releases newer than from, up to the latest one
+// matching the query, newest first. The releases in between are returned
+// even if out of the version range of the query, e.g. the 1.6 releases of
+// "foo@1.7" from 1.5.0. Only the SchemeVersion of from is used if the query
+// has a Scheme.
+func (q *Query) Changelog(ctx context.Context, fetcher Fetcher, from releases.Release) (releases.Releases, error) {
+	if q.Scheme != nil {
+		if _, err := q.Scheme.Parse(from.SchemeVersion()); err != nil {
+			return nil, err
+		}
+	}
+
+	rs, err := q.candidates(ctx, fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := q.selectAll(rs)
+	if len(matched) == 0 {
+		return nil, ErrNoRelease
+	}
+	if q.Scheme != nil {
+		return rs.BetweenInScheme(q.Scheme, from, matched[0]), nil
+	}
+	return rs.Between(from, matched[0]), nil
+}
@@ -0,0 +1,143 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/parser"
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+type staticFetcher releases.Releases
+
+func (f staticFetcher) Fetch(ctx context.Context, softwareId string) (releases.Releases, error) {
+	return releases.Releases(f), nil
+}
+
+func TestChangelog(t *testing.T) {
+	var f staticFetcher
+	for _, s := range []string{"1.8.0", "1.7.1", "1.7.0", "1.6.0", "1.5.1", "1.5.0", "1.4.0"} {
+		f = append(f, releases.Release{OriginalName: s, Version: semver.MustParse(s)})
+	}
+
+	testcases := []struct {
+		query    string
+		from     string
+		expected []string
+	}{
+		{"foo@1.7", "1.5.0", []string{"1.7.1", "1.7.0", "1.6.0", "1.5.1"}},
+		{"foo", "1.7.0", []string{"1.8.0", "1.7.1"}},
+		{"foo@1.7", "1.7.1", nil},
+		{"foo@1.7:scheme=semver", "1.5.0", []string{"1.7.1", "1.7.0", "1.6.0", "1.5.1"}},
+	}
+	for _, tc := range testcases {
+		q, err := parser.Parse(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from := releases.Release{OriginalName: tc.from, Version: semver.MustParse(tc.from)}
+		rs, err := q.Changelog(context.Background(), f, from)
+		if err != nil {
+			t.Errorf("%s from %s: %v", tc.query, tc.from, err)
+			continue
+		}
+		var got []string
+		for _, r := range rs {
+			got = append(got, r.OriginalName)
+		}
+		if diff := cmp.Diff(tc.expected, got); diff != "" {
+			t.Errorf("%s from %s (-want +got):\n%s", tc.query, tc.from, diff)
+		}
+	}
+}
+
+func TestExecuteWithScheme(t *testing.T) {
+	// As the providers return them: names which fail to parse as semver are
+	// kept unparsed, and git describe versions are taken for prereleases.
+	f := staticFetcher{
+		{OriginalName: "r26", Version: semver.MustParse("26.0.0")},
+		{OriginalName: "r25b", Unparsed: true},
+		{OriginalName: "r25", Version: semver.MustParse("25.0.0")},
+		{OriginalName: "r24", Version: semver.MustParse("24.0.0")},
+		{OriginalName: "v1.3.0-rc.1", Version: semver.MustParse("1.3.0-rc.1"), Prerelease: true},
+		{OriginalName: "v1.2.3-4-g1234abc", Version: semver.MustParse("1.2.3-4-g1234abc"), Prerelease: true},
+		{OriginalName: "v1.2.3-10-gabcdef0", Version: semver.MustParse("1.2.3-10-gabcdef0"), Prerelease: true},
+		{OriginalName: "v1.2.3", Version: semver.MustParse("1.2.3")},
+		{OriginalName: "1.0rc1", Unparsed: true},
+		{OriginalName: "1.0", Version: semver.MustParse("1.0.0")},
+	}
+
+	testcases := []struct {
+		query    string
+		expected []string
+	}{
+		{"foo", []string{"r26", "r25", "r24", "v1.2.3", "1.0"}},
+		{"foo@25:scheme=calver", []string{"r25"}},
+		{"foo>=25:scheme=calver", []string{"r26", "r25"}},
+		{"foo@1.2:scheme=git", []string{"v1.2.3-10-gabcdef0", "v1.2.3-4-g1234abc", "v1.2.3"}},
+		{"foo@1.2.3:scheme=git", []string{"v1.2.3"}},
+		{"foo@1.0:scheme=pep440", []string{"1.0"}},
+		{"foo<1.0:scheme=pep440:prerelease", []string{"1.0rc1"}},
+	}
+	for _, tc := range testcases {
+		q, err := parser.Parse(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs, err := q.Execute(context.Background(), f)
+		if err != nil {
+			t.Errorf("%s: %v", tc.query, err)
+			continue
+		}
+		var got []string
+		for _, r := range rs {
+			got = append(got, r.OriginalName)
+		}
+		if diff := cmp.Diff(tc.expected, got); diff != "" {
+			t.Errorf("%s (-want +got):\n%s", tc.query, diff)
+		}
+	}
+}
+
+func TestSchemeWithPrefix(t *testing.T) {
+	// As the github provider returns the tags of "foo:prefix=service/s3/".
+	var f staticFetcher
+	for _, s := range []string{"v1.2.3", "v1.1.0", "v1.0.0"} {
+		f = append(f, releases.Release{
+			OriginalName:  "service/s3/" + s,
+			Version:       semver.MustParse(s[1:]),
+			VersionString: s,
+		})
+	}
+
+	q, err := parser.Parse("foo@1:scheme=semver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := q.Execute(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rs {
+		got = append(got, r.OriginalName)
+	}
+	if diff := cmp.Diff([]string{"service/s3/v1.2.3", "service/s3/v1.1.0", "service/s3/v1.0.0"}, got); diff != "" {
+		t.Errorf("Execute (-want +got):\n%s", diff)
+	}
+
+	rs, err = q.Changelog(context.Background(), f, f[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, r := range rs {
+		got = append(got, r.OriginalName)
+	}
+	if diff := cmp.Diff([]string{"service/s3/v1.2.3", "service/s3/v1.1.0"}, got); diff != "" {
+		t.Errorf("Changelog (-want +got):\n%s", diff)
+	}
+}
@@ -1,19 +1,157 @@
 package releases
 
 import (
+	"encoding/json"
 	"errors"
+	"net/url"
+	"path"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"go.uber.org/zap"
+
+	"github.com/IPA-CyberLab/latest/pkg/scheme"
 )
 
+// Asset is a file published as a part of a Release. Fields other than the URL
+// are filled as far as the provider knows them.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Size in bytes, or zero if unknown.
+	Size          int64  `json:"size,omitempty"`
+	ContentType   string `json:"content_type,omitempty"`
+	DownloadCount int64  `json:"download_count,omitempty"`
+
+	// Digests maps a digest algorithm name ("sha1", "sha256" or "sha512") to
+	// the hex digest of the asset, when published along with the release.
+	Digests map[string]string `json:"digests,omitempty"`
+	// ChecksumURLs maps a digest algorithm name to the URL of the file
+	// containing the hex digest.
+	ChecksumURLs map[string]string `json:"checksum_urls,omitempty"`
+	SignatureURL string            `json:"signature_url,omitempty"`
+
+	// OS and Arch are in the GOOS and GOARCH notation, e.g. "linux" and
+	// "amd64". Empty if the asset is not platform specific, or unknown.
+	OS   string `json:"os,omitempty"`
+	Arch string `json:"arch,omitempty"`
+	// Kind is e.g. "archive", "installer" or "source", or the binary in it
+	// when a release ships many, e.g. "chromedriver".
+	Kind string `json:"kind,omitempty"`
+}
+
+// NewAsset returns an Asset named after the last path element of its URL.
+func NewAsset(assetURL string) Asset {
+	name := assetURL
+	if u, err := url.Parse(assetURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	return Asset{Name: name, URL: assetURL}
+}
+
+// NewAssets returns the Assets of the URLs with no other metadata.
+func NewAssets(assetURLs []string) []Asset {
+	if assetURLs == nil {
+		return nil
+	}
+	as := make([]Asset, 0, len(assetURLs))
+	for _, u := range assetURLs {
+		as = append(as, NewAsset(u))
+	}
+	return as
+}
+
 type Release struct {
 	OriginalName string         `json:"original_name"`
 	Version      semver.Version `json:"version"`
 	Prerelease   bool           `json:"prerelease"`
-	AssetURLs    []string       `json:"asset_urls"`
+	Assets       []Asset        `json:"assets"`
+	PublishedAt  time.Time      `json:"published_at"`
+	// Notes is the release notes text, if the provider publishes them along
+	// with the release.
+	Notes string `json:"notes,omitempty"`
+	// ChangelogURL points to a human readable list of the changes.
+	ChangelogURL string `json:"changelog_url,omitempty"`
+	// Source identifies where the release was found when a provider queries
+	// multiple upstreams, e.g. the id of a Maven repository.
+	Source string `json:"source,omitempty"`
+	// Components are all the numeric components of the version, including
+	// the ones past the patch version which semver cannot represent, e.g.
+	// [1 2 3 4] for "1.2.3.4". Empty if the version has no more components
+	// than Version.
+	Components []uint64 `json:"components,omitempty"`
+	// VersionString is the part of OriginalName the provider parsed the
+	// version from, e.g. "v1.2.3" of the monorepo tag "service/s3/v1.2.3".
+	// Empty if it is OriginalName as a whole.
+	VersionString string `json:"version_string,omitempty"`
+	// Unparsed is set if the provider failed to parse the version from
+	// OriginalName, leaving it to the version scheme of the query. Version
+	// is zero.
+	Unparsed bool `json:"unparsed,omitempty"`
+}
+
+// components returns Components, or the ones of Version if not set.
+func (r Release) components() []uint64 {
+	if len(r.Components) > 0 {
+		return r.Components
+	}
+	return []uint64{r.Version.Major, r.Version.Minor, r.Version.Patch}
+}
+
+// Compare returns negative, zero or positive if r is older than, the same as
+// or newer than o. Unlike semver, the components past the patch version are
+// taken into account, e.g. "1.2.3.5-beta" is newer than "1.2.3.4". Build
+// metadata is ignored as in semver.
+func (r Release) Compare(o Release) int {
+	rcs, ocs := r.components(), o.components()
+	for i := 0; i < len(rcs) || i < len(ocs); i++ {
+		var rc, oc uint64
+		if i < len(rcs) {
+			rc = rcs[i]
+		}
+		if i < len(ocs) {
+			oc = ocs[i]
+		}
+		if rc != oc {
+			if rc < oc {
+				return -1
+			}
+			return 1
+		}
+	}
+	return r.Version.Compare(o.Version)
+}
+
+// AssetURLs returns the URLs of the assets.
+func (r Release) AssetURLs() []string {
+	us := make([]string, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		us = append(us, a.URL)
+	}
+	return us
+}
+
+// MarshalJSON adds "asset_urls", the URLs of the assets, for the consumers
+// of the JSON output predating "assets". It is ignored when unmarshaling.
+func (r Release) MarshalJSON() ([]byte, error) {
+	type release Release
+	return json.Marshal(struct {
+		release
+		AssetURLs []string `json:"asset_urls"`
+	}{release(r), r.AssetURLs()})
+}
+
+// Asset returns the asset at assetURL.
+func (r Release) Asset(assetURL string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.URL == assetURL {
+			return a, true
+		}
+	}
+	return Asset{}, false
 }
 
 type Releases []Release
@@ -21,6 +159,124 @@ type Releases []Release
 var NotFoundErr = errors.New("No matching Release found.")
 var AssetNotFoundErr = errors.New("No matching asset found.")
 
+// Sort orders the releases newest first.
+func (rs Releases) Sort() {
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Compare(rs[j]) > 0
+	})
+}
+
+// isDuplicateOf reports whether r is the same release as o, i.e. of the same
+// version and either the same name or the same build metadata. Releases
+// differing only in build metadata, such as Maven qualifiers "31.1-jre" and
+// "31.1-android", are distinct.
+func (r Release) isDuplicateOf(o Release) bool {
+	if r.Compare(o) != 0 {
+		return false
+	}
+	if r.OriginalName == o.OriginalName {
+		return true
+	}
+	if r.Unparsed || o.Unparsed {
+		return false
+	}
+	if len(r.Version.Build) != len(o.Version.Build) {
+		return false
+	}
+	for i := range r.Version.Build {
+		if r.Version.Build[i] != o.Version.Build[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Dedup drops the releases duplicating a preceding one, such as "v1.2"
+// following "1.2.0". rs must be sorted.
+func (rs Releases) Dedup() Releases {
+	ret := make(Releases, 0, len(rs))
+	for _, r := range rs {
+		if !ret.hasDuplicateOf(r) {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// hasDuplicateOf reports whether the trailing releases of the same version
+// as r include a duplicate of it.
+func (rs Releases) hasDuplicateOf(r Release) bool {
+	for i := len(rs) - 1; i >= 0 && rs[i].Compare(r) == 0; i-- {
+		if r.isDuplicateOf(rs[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveUnparsed drops the releases whose versions the provider failed to
+// parse.
+func (rs Releases) RemoveUnparsed() Releases {
+	ret := make(Releases, 0, len(rs))
+	for _, r := range rs {
+		if r.Unparsed {
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// SchemeVersion returns the version string of r to parse in a version scheme.
+func (r Release) SchemeVersion() string {
+	s := r.OriginalName
+	if r.VersionString != "" {
+		s = r.VersionString
+	}
+	return scheme.TrimComponent(s)
+}
+
+// WithScheme re-parses the versions from the original names with the version
+// scheme s, including the ones the provider failed to parse, and orders the
+// releases newest first as s does. Releases whose names are not versions in s
+// are dropped.
+func (rs Releases) WithScheme(s scheme.Scheme) Releases {
+	type parsed struct {
+		r       Release
+		version string
+	}
+	ps := make([]parsed, 0, len(rs))
+	for _, r := range rs {
+		version := r.SchemeVersion()
+		v, err := s.Parse(version)
+		if err != nil {
+			zap.S().Debugf("Dropping release %q: %v", r.OriginalName, err)
+			continue
+		}
+		// s tells the prereleases, which a provider parsing as semver may
+		// have missed, or mistaken e.g. "1.2.3-4-g1234abc" for.
+		switch {
+		case len(v.Pre) > 0:
+			r.Prerelease = true
+		case len(r.Version.Pre) > 0:
+			r.Prerelease = false
+		}
+		r.Version = v
+		r.Unparsed = false
+		ps = append(ps, parsed{r: r, version: version})
+	}
+
+	sort.SliceStable(ps, func(i, j int) bool {
+		return s.Compare(ps[i].version, ps[j].version) > 0
+	})
+
+	ret := make(Releases, 0, len(ps))
+	for _, p := range ps {
+		ret = append(ret, p.r)
+	}
+	return ret
+}
+
 func (rs Releases) SelectAll(vrange semver.Range) Releases {
 	selected := make(Releases, 0)
 
@@ -35,6 +291,22 @@ func (rs Releases) SelectAll(vrange semver.Range) Releases {
 	return selected
 }
 
+// SelectAllInScheme is SelectAll matching the versions in the version scheme
+// s. rs must be WithScheme(s)-ed.
+func (rs Releases) SelectAllInScheme(s scheme.Scheme, vrange scheme.Range) Releases {
+	selected := make(Releases, 0)
+
+	for _, r := range rs {
+		if !vrange.Match(s, r.SchemeVersion()) {
+			continue
+		}
+
+		selected = append(selected, r)
+	}
+
+	return selected
+}
+
 func (rs Releases) Select(vrange semver.Range) (Release, error) {
 	for _, r := range rs {
 		if vrange != nil && !vrange(r.Version) {
@@ -46,6 +318,38 @@ func (rs Releases) Select(vrange semver.Range) (Release, error) {
 	return Release{}, NotFoundErr
 }
 
+// Between returns the releases newer than from, and not newer than to.
+func (rs Releases) Between(from, to Release) Releases {
+	selected := make(Releases, 0)
+
+	for _, r := range rs {
+		if r.Compare(from) <= 0 || r.Compare(to) > 0 {
+			continue
+		}
+
+		selected = append(selected, r)
+	}
+
+	return selected
+}
+
+// BetweenInScheme is Between comparing the versions in the version scheme s.
+// rs must be WithScheme(s)-ed.
+func (rs Releases) BetweenInScheme(s scheme.Scheme, from, to Release) Releases {
+	selected := make(Releases, 0)
+
+	for _, r := range rs {
+		v := r.SchemeVersion()
+		if s.Compare(v, from.SchemeVersion()) <= 0 || s.Compare(v, to.SchemeVersion()) > 0 {
+			continue
+		}
+
+		selected = append(selected, r)
+	}
+
+	return selected
+}
+
 func (rs Releases) RemovePrerelease() Releases {
 	ret := make(Releases, 0, len(rs))
 
@@ -59,7 +363,7 @@ func (rs Releases) RemovePrerelease() Releases {
 	return ret
 }
 
-func filter(ss []string, needle string) []string {
+func filter(as []Asset, needle string) []Asset {
 	zap.S().Debugf("asset filter %q", needle)
 
 	var invert bool
@@ -70,28 +374,28 @@ func filter(ss []string, needle string) []string {
 		invert = false
 	}
 
-	filtered := make([]string, 0, len(ss))
-	for _, s := range ss {
-		match := strings.Contains(strings.ToLower(s), needle)
+	filtered := make([]Asset, 0, len(as))
+	for _, a := range as {
+		match := strings.Contains(strings.ToLower(a.URL), needle)
 		if match == !invert {
-			// zap.S().Debugf("%s %t match %s\n", needle, invert, strings.ToLower(s))
-			filtered = append(filtered, s)
+			// zap.S().Debugf("%s %t match %s\n", needle, invert, strings.ToLower(a.URL))
+			filtered = append(filtered, a)
 		}
 	}
 	return filtered
 }
 
 func (r *Release) FilterAssets(needle string) {
-	r.AssetURLs = filter(r.AssetURLs, needle)
+	r.Assets = filter(r.Assets, needle)
 }
 
-func filterIfMatches(ss []string, needle string) []string {
-	filtered := filter(ss, needle)
+func filterIfMatches(as []Asset, needle string) []Asset {
+	filtered := filter(as, needle)
 
 	if len(filtered) > 0 {
 		return filtered
 	} else {
-		return ss
+		return as
 	}
 }
 
@@ -113,6 +417,6 @@ func (r *Release) PickAsset() {
 	}
 
 	for _, f := range filters {
-		r.AssetURLs = filterIfMatches(r.AssetURLs, f)
+		r.Assets = filterIfMatches(r.Assets, f)
 	}
 }
@@ -0,0 +1,86 @@
+package releases_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/IPA-CyberLab/latest/pkg/releases"
+)
+
+func TestSortAndDedup(t *testing.T) {
+	rs := releases.Releases{
+		{OriginalName: "1.2.3.4", Version: semver.MustParse("1.2.3"), Components: []uint64{1, 2, 3, 4}},
+		{OriginalName: "1.2.3.10", Version: semver.MustParse("1.2.3"), Components: []uint64{1, 2, 3, 10}},
+		{OriginalName: "v1.2.3", Version: semver.MustParse("1.2.3")},
+		{OriginalName: "1.2.3.5", Version: semver.MustParse("1.2.3"), Components: []uint64{1, 2, 3, 5}},
+		{OriginalName: "1.2.3", Version: semver.MustParse("1.2.3")},
+		{OriginalName: "1.2.3+4", Version: semver.MustParse("1.2.3+4")},
+		{OriginalName: "1.2.3.5-beta", Version: semver.MustParse("1.2.3-beta"), Components: []uint64{1, 2, 3, 5}},
+		{OriginalName: "1.2.4", Version: semver.MustParse("1.2.4")},
+	}
+	rs.Sort()
+	rs = rs.Dedup()
+
+	var actual []string
+	for _, r := range rs {
+		actual = append(actual, r.OriginalName)
+	}
+	expected := []string{"1.2.4", "1.2.3.10", "1.2.3.5", "1.2.3.5-beta", "1.2.3.4", "v1.2.3", "1.2.3+4"}
+	if diffstr := cmp.Diff(actual, expected); diffstr != "" {
+		t.Errorf("Unexpected diff: %s", diffstr)
+	}
+}
+
+func TestDedupQualifiers(t *testing.T) {
+	rs := releases.Releases{
+		{OriginalName: "31.1-jre", Version: semver.MustParse("31.1.0+jre"), Source: "central"},
+		{OriginalName: "31.1-android", Version: semver.MustParse("31.1.0+android"), Source: "central"},
+		{OriginalName: "31.1-jre", Version: semver.MustParse("31.1.0+jre"), Source: "mirror"},
+		{OriginalName: "31.0.1-jre", Version: semver.MustParse("31.0.1+jre")},
+		{OriginalName: "31.0.1.jre", Version: semver.MustParse("31.0.1+jre")},
+	}
+	rs.Sort()
+	rs = rs.Dedup()
+
+	var actual []string
+	for _, r := range rs {
+		actual = append(actual, r.OriginalName+" "+r.Source)
+	}
+	expected := []string{"31.1-jre central", "31.1-android central", "31.0.1-jre "}
+	if diffstr := cmp.Diff(expected, actual); diffstr != "" {
+		t.Errorf("(-want +got):\n%s", diffstr)
+	}
+}
+
+func TestMarshalJSONAssetURLs(t *testing.T) {
+	r := releases.Release{
+		OriginalName: "1.2.3",
+		Version:      semver.MustParse("1.2.3"),
+		Assets:       releases.NewAssets([]string{"https://example.com/foo-1.2.3.tar.gz"}),
+	}
+	bs, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{"https://example.com/foo-1.2.3.tar.gz"}, m["asset_urls"]); diff != "" {
+		t.Errorf("asset_urls (-want +got):\n%s", diff)
+	}
+	if _, ok := m["assets"]; !ok {
+		t.Errorf("assets missing: %s", bs)
+	}
+
+	var got releases.Release
+	if err := json.Unmarshal(bs, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(r, got); diff != "" {
+		t.Errorf("round trip (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,146 @@
+package scheme
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Debian orders versions of Debian packages: "[epoch:]upstream[-revision]".
+// https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+type Debian struct{}
+
+type debianVersion struct {
+	epoch    uint64
+	upstream string
+	revision string
+}
+
+var reDebianUpstream = regexp.MustCompile(`^[0-9][A-Za-z0-9.+~\-]*$`)
+
+func parseDebian(s string) (debianVersion, error) {
+	var v debianVersion
+
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		epoch, err := strconv.ParseUint(s[:i], 10, 64)
+		if err != nil {
+			return debianVersion{}, fmt.Errorf("Failed to parse epoch of Debian version %q: %w", s, err)
+		}
+		v.epoch = epoch
+		s = s[i+1:]
+	}
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		v.upstream, v.revision = s[:i], s[i+1:]
+	} else {
+		v.upstream = s
+	}
+
+	if !reDebianUpstream.MatchString(v.upstream) {
+		return debianVersion{}, fmt.Errorf("Failed to parse Debian version %q", s)
+	}
+	return v, nil
+}
+
+var reLeadingNums = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+func (Debian) Parse(s string) (semver.Version, error) {
+	v, err := parseDebian(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	numstr := reLeadingNums.FindString(v.upstream)
+	nums, err := parseNums(strings.Split(numstr, "."))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("Failed to parse Debian version %q: %w", s, err)
+	}
+
+	// "1.0~rc1" sorts before "1.0".
+	rest := v.upstream[len(numstr):]
+	var pre []semver.PRVersion
+	var build []string
+	if i := strings.IndexByte(rest, '~'); i >= 0 {
+		pre = prVersionsOf(rest[i+1:])
+		rest = rest[:i]
+	}
+	build = append(identifiersOf(rest), identifiersOf(v.revision)...)
+
+	return newSemver(nums, pre, build), nil
+}
+
+// debianOrder is the weight of a character in the non-digit parts, as in
+// order() of dpkg: "~" sorts before anything, even the end of the part, and
+// letters sort before non-letters.
+func debianOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+// debianCompareParts implements verrevcmp() of dpkg.
+func debianCompareParts(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := debianOrder(a, i), debianOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+func (Debian) Compare(a, b string) int {
+	va, _ := parseDebian(a)
+	vb, _ := parseDebian(b)
+
+	if c := compareUint(va.epoch, vb.epoch); c != 0 {
+		return c
+	}
+	if c := debianCompareParts(va.upstream, vb.upstream); c != 0 {
+		return c
+	}
+	return debianCompareParts(va.revision, vb.revision)
+}
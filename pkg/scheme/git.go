@@ -0,0 +1,59 @@
+package scheme
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/blang/semver/v4"
+)
+
+// Git parses versions as output by "git describe", "TAG-N-gHASH", which are
+// N commits past the semver TAG, e.g. "1.2.3-4-g1234abc". Versions without
+// the suffix are the tags themselves. The suffix is mapped to semver build
+// metadata, e.g. "1.2.3+4.g1234abc", instead of a prerelease.
+type Git struct{}
+
+var reGitDescribe = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]+)$`)
+
+func (Git) describe(s string) (tag semver.Version, n uint64, hash string, err error) {
+	tagStr := s
+	if ms := reGitDescribe.FindStringSubmatch(s); len(ms) != 0 {
+		tagStr, hash = ms[1], ms[3]
+		n, err = strconv.ParseUint(ms[2], 10, 64)
+		if err != nil {
+			return semver.Version{}, 0, "", fmt.Errorf("Failed to parse git describe version %q: %w", s, err)
+		}
+	}
+	tag, err = semver.ParseTolerant(tagStr)
+	if err != nil {
+		return semver.Version{}, 0, "", fmt.Errorf("Failed to parse git describe version %q: %w", s, err)
+	}
+	return tag, n, hash, nil
+}
+
+func (g Git) Parse(s string) (semver.Version, error) {
+	v, n, hash, err := g.describe(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	if hash != "" {
+		v.Build = append(v.Build, strconv.FormatUint(n, 10), "g"+hash)
+	}
+	return v, nil
+}
+
+func (g Git) Compare(a, b string) int {
+	va, na, _, _ := g.describe(a)
+	vb, nb, _, _ := g.describe(b)
+	if c := va.Compare(vb); c != 0 {
+		return c
+	}
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
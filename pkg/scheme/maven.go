@@ -0,0 +1,324 @@
+package scheme
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// ComparableVersion implements the version ordering of Maven, as in
+// org.apache.maven.artifact.versioning.ComparableVersion.
+// https://maven.apache.org/pom.html#Version_Order_Specification
+type ComparableVersion struct {
+	items listItem
+}
+
+type item interface {
+	// compare returns negative, zero or positive if the receiver is less,
+	// equal or greater than other. other may be nil, which stands for a
+	// missing item.
+	compare(other item) int
+	isNull() bool
+}
+
+type intItem string
+type stringItem string
+type listItem []item
+
+var qualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var qualifierAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+var releaseQualifierIndex = comparableQualifier("")
+
+func comparableQualifier(q string) string {
+	for i, known := range qualifiers {
+		if q == known {
+			return strconv.Itoa(i)
+		}
+	}
+	return strconv.Itoa(len(qualifiers)) + "-" + q
+}
+
+func newStringItem(s string, followedByDigit bool) stringItem {
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, ok := qualifierAliases[s]; ok {
+		s = alias
+	}
+	return stringItem(s)
+}
+
+func newIntItem(s string) intItem {
+	s = strings.TrimLeft(s, "0")
+	return intItem(s)
+}
+
+func (i intItem) isNull() bool { return i == "" }
+
+func (i intItem) compare(other item) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case intItem:
+		if len(i) != len(o) {
+			return len(i) - len(o)
+		}
+		return strings.Compare(string(i), string(o))
+	default:
+		// 1.1 > 1-sp, 1.1 > 1-1
+		return 1
+	}
+}
+
+func (s stringItem) isNull() bool { return comparableQualifier(string(s)) == releaseQualifierIndex }
+
+func (s stringItem) compare(other item) int {
+	switch o := other.(type) {
+	case nil:
+		// 1-rc < 1, 1-ga > 1
+		return strings.Compare(comparableQualifier(string(s)), releaseQualifierIndex)
+	case intItem:
+		return -1
+	case stringItem:
+		return strings.Compare(comparableQualifier(string(s)), comparableQualifier(string(o)))
+	default:
+		return -1
+	}
+}
+
+func (l listItem) isNull() bool { return len(l) == 0 }
+
+func (l listItem) compare(other item) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case intItem:
+		return -1
+	case stringItem:
+		return 1
+	case listItem:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var left, right item
+			if i < len(l) {
+				left = l[i]
+			}
+			if i < len(o) {
+				right = o[i]
+			}
+
+			var c int
+			if left == nil {
+				if right != nil {
+					c = -right.compare(nil)
+				}
+			} else {
+				c = left.compare(right)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		panic("not reached.")
+	}
+}
+
+func (l *listItem) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		last := (*l)[i]
+		if last.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, ok := last.(listItem); !ok {
+			break
+		}
+	}
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func parseItem(isDigit bool, s string) item {
+	if isDigit {
+		return newIntItem(s)
+	}
+	return newStringItem(s, false)
+}
+
+// ParseComparableVersion never fails, as any string is a valid Maven version.
+func ParseComparableVersion(version string) ComparableVersion {
+	version = strings.ToLower(version)
+
+	// Nested lists are built bottom-up, so keep the chain of open lists
+	// and fold them into their parents at the end.
+	stack := []listItem{{}}
+	push := func() {
+		stack = append(stack, listItem{})
+	}
+	add := func(it item) {
+		stack[len(stack)-1] = append(stack[len(stack)-1], it)
+	}
+
+	digit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				add(newIntItem("0"))
+			} else {
+				add(parseItem(digit, version[start:i]))
+			}
+			start = i + 1
+
+		case c == '-':
+			if i == start {
+				add(newIntItem("0"))
+			} else {
+				add(parseItem(digit, version[start:i]))
+			}
+			start = i + 1
+			push()
+
+		case isDigit(c):
+			if !digit && i > start {
+				add(newStringItem(version[start:i], true))
+				start = i
+				push()
+			}
+			digit = true
+
+		default:
+			if digit && i > start {
+				add(parseItem(true, version[start:i]))
+				start = i
+				push()
+			}
+			digit = false
+		}
+	}
+	if len(version) > start {
+		add(parseItem(digit, version[start:]))
+	}
+
+	for len(stack) > 1 {
+		top := stack[len(stack)-1]
+		top.normalize()
+		stack = stack[:len(stack)-1]
+		add(top)
+	}
+	stack[0].normalize()
+
+	return ComparableVersion{items: stack[0]}
+}
+
+func (v ComparableVersion) Compare(o ComparableVersion) int {
+	return v.items.compare(o.items)
+}
+
+var prereleaseQualifiers = map[string]struct{}{
+	"alpha":     {},
+	"beta":      {},
+	"milestone": {},
+	"rc":        {},
+	"snapshot":  {},
+}
+
+func hasPrereleaseQualifier(l listItem) bool {
+	for _, it := range l {
+		switch v := it.(type) {
+		case stringItem:
+			if _, ok := prereleaseQualifiers[string(v)]; ok {
+				return true
+			}
+		case listItem:
+			if hasPrereleaseQualifier(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPrerelease reports if the version has any of the alpha, beta, milestone,
+// rc or snapshot qualifiers. Other qualifiers such as "jre" or "sp" do not
+// make a version a prerelease.
+func (v ComparableVersion) IsPrerelease() bool {
+	return hasPrereleaseQualifier(v.items)
+}
+
+var reNumericPrefix = regexp.MustCompile(`^(\d+)(\.(\d+))?(\.(\d+))?[.\-]?(.*)$`)
+var reNonAlnum = regexp.MustCompile(`[^0-9a-z]+`)
+var reAlphaDigit = regexp.MustCompile(`([a-z])([0-9])`)
+var reDigitAlpha = regexp.MustCompile(`([0-9])([a-z])`)
+
+// MavenToSemver maps a Maven version to semver, so that it can be matched
+// against version ranges. The leading numeric components become the semver
+// major.minor.patch, and the qualifiers become the semver prerelease if
+// prerelease is true, or the build metadata otherwise.
+func MavenToSemver(version string, prerelease bool) (semver.Version, bool) {
+	ms := reNumericPrefix.FindStringSubmatch(version)
+	if len(ms) == 0 {
+		return semver.Version{}, false
+	}
+
+	var v semver.Version
+	var err error
+	if v.Major, err = strconv.ParseUint(ms[1], 10, 64); err != nil {
+		return semver.Version{}, false
+	}
+	if ms[3] != "" {
+		if v.Minor, err = strconv.ParseUint(ms[3], 10, 64); err != nil {
+			return semver.Version{}, false
+		}
+	}
+	if ms[5] != "" {
+		if v.Patch, err = strconv.ParseUint(ms[5], 10, 64); err != nil {
+			return semver.Version{}, false
+		}
+	}
+
+	// "RC1" -> "rc.1"
+	rest := strings.ToLower(ms[6])
+	rest = reAlphaDigit.ReplaceAllString(rest, "$1.$2")
+	rest = reDigitAlpha.ReplaceAllString(rest, "$1.$2")
+	rest = strings.Trim(reNonAlnum.ReplaceAllString(rest, "."), ".")
+	if rest == "" {
+		return v, true
+	}
+	for _, part := range strings.Split(rest, ".") {
+		if prerelease {
+			pr, err := semver.NewPRVersion(part)
+			if err != nil {
+				// e.g. leading zeros in a numeric part
+				pr = semver.PRVersion{VersionStr: "x" + part}
+			}
+			v.Pre = append(v.Pre, pr)
+		} else {
+			v.Build = append(v.Build, part)
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,106 @@
+package scheme
+
+import (
+	"testing"
+)
+
+func TestComparableVersionOrder(t *testing.T) {
+	// Taken from the ComparableVersionTest of Apache Maven.
+	ordered := [][]string{
+		{
+			"1-alpha2snapshot", "1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11",
+			"1-rc", "1-cr2", "1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def",
+			"1-pom-1", "1-1-snapshot", "1-1", "1-2", "1-123",
+		},
+		{
+			"2.0", "2-1", "2.0.a", "2.0.0.a", "2.0.2", "2.0.123", "2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1",
+			"2.1.0.1", "2.2", "2.123", "11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11",
+			"11.a", "11b", "11c", "11m",
+		},
+		{
+			"31.0-android", "31.0-jre", "31.1-android", "31.1-jre",
+		},
+	}
+
+	for _, vs := range ordered {
+		for i := 0; i < len(vs); i++ {
+			for j := i + 1; j < len(vs); j++ {
+				lo, hi := ParseComparableVersion(vs[i]), ParseComparableVersion(vs[j])
+				if c := lo.Compare(hi); c >= 0 {
+					t.Errorf("Expected %q < %q, got %d", vs[i], vs[j], c)
+				}
+				if c := hi.Compare(lo); c <= 0 {
+					t.Errorf("Expected %q > %q, got %d", vs[j], vs[i], c)
+				}
+			}
+		}
+	}
+}
+
+func TestComparableVersionEquality(t *testing.T) {
+	equal := [][]string{
+		{"1", "1.0", "1.0.0", "1-ga", "1.0-final", "1-release", "1.0.0.Final"},
+		{"1-rc1", "1-cr1", "1-RC-1"},
+		{"1a1", "1-a1", "1-alpha-1", "1.0-ALPHA1"},
+	}
+
+	for _, vs := range equal {
+		for _, a := range vs {
+			for _, b := range vs {
+				if c := ParseComparableVersion(a).Compare(ParseComparableVersion(b)); c != 0 {
+					t.Errorf("Expected %q == %q, got %d", a, b, c)
+				}
+			}
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	testcases := []struct {
+		input      string
+		prerelease bool
+	}{
+		{"1.0", false},
+		{"5.4.2.Final", false},
+		{"31.1-jre", false},
+		{"1.0-sp1", false},
+		{"1.0-RC1", true},
+		{"2.0.0-M3", true},
+		{"1.0-SNAPSHOT", true},
+		{"1.0-beta-2", true},
+		{"1.0a1", true},
+	}
+
+	for _, tc := range testcases {
+		actual := ParseComparableVersion(tc.input).IsPrerelease()
+		if actual != tc.prerelease {
+			t.Errorf("IsPrerelease(%q) expected %t actual %t", tc.input, tc.prerelease, actual)
+		}
+	}
+}
+
+func TestMavenToSemver(t *testing.T) {
+	testcases := []struct {
+		input      string
+		prerelease bool
+		expected   string
+	}{
+		{"1.2.3", false, "1.2.3"},
+		{"31.1-jre", false, "31.1.0+jre"},
+		{"5.4.2.Final", false, "5.4.2+final"},
+		{"1.0-RC1", true, "1.0.0-rc.1"},
+		{"2.0.0-M3", true, "2.0.0-m.3"},
+		{"1.0-SNAPSHOT", true, "1.0.0-snapshot"},
+	}
+
+	for _, tc := range testcases {
+		actual, ok := MavenToSemver(tc.input, tc.prerelease)
+		if !ok {
+			t.Errorf("MavenToSemver(%q) failed", tc.input)
+			continue
+		}
+		if actual.String() != tc.expected {
+			t.Errorf("MavenToSemver(%q) expected %s actual %s", tc.input, tc.expected, actual)
+		}
+	}
+}
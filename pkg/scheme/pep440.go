@@ -0,0 +1,174 @@
+package scheme
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// PEP440 orders versions of Python packages.
+// https://peps.python.org/pep-0440/
+type PEP440 struct{}
+
+var rePEP440 = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+var pep440PreKinds = map[string]string{
+	"a":       "a",
+	"alpha":   "a",
+	"b":       "b",
+	"beta":    "b",
+	"c":       "rc",
+	"rc":      "rc",
+	"pre":     "rc",
+	"preview": "rc",
+}
+
+var pep440PreOrder = map[string]int{"a": 0, "b": 1, "rc": 2}
+
+type pep440Version struct {
+	epoch   uint64
+	release []uint64
+	preKind string // "" if not a prerelease
+	pre     uint64
+	hasPost bool
+	post    uint64
+	hasDev  bool
+	dev     uint64
+	local   string
+}
+
+func atoiOrZero(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+func parsePEP440(s string) (pep440Version, error) {
+	ms := rePEP440.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if len(ms) == 0 {
+		return pep440Version{}, fmt.Errorf("Failed to parse PEP 440 version %q", s)
+	}
+
+	release, err := parseNums(strings.Split(ms[2], "."))
+	if err != nil {
+		return pep440Version{}, fmt.Errorf("Failed to parse PEP 440 version %q: %w", s, err)
+	}
+
+	v := pep440Version{
+		epoch:   atoiOrZero(ms[1]),
+		release: release,
+		local:   ms[10],
+	}
+	if ms[3] != "" {
+		v.preKind = pep440PreKinds[ms[3]]
+		v.pre = atoiOrZero(ms[4])
+	}
+	if ms[5] != "" {
+		// "1.0-1" is the implicit form of "1.0.post1".
+		v.hasPost, v.post = true, atoiOrZero(ms[5])
+	} else if ms[6] != "" {
+		v.hasPost, v.post = true, atoiOrZero(ms[7])
+	}
+	if ms[8] != "" {
+		v.hasDev, v.dev = true, atoiOrZero(ms[9])
+	}
+	return v, nil
+}
+
+func (PEP440) Parse(s string) (semver.Version, error) {
+	v, err := parsePEP440(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	var pre []semver.PRVersion
+	if v.preKind != "" {
+		pre = append(pre, semver.PRVersion{VersionStr: v.preKind}, semver.PRVersion{VersionNum: v.pre, IsNum: true})
+	}
+	if v.hasDev {
+		pre = append(pre, semver.PRVersion{VersionStr: "dev"}, semver.PRVersion{VersionNum: v.dev, IsNum: true})
+	}
+	var build []string
+	if v.hasPost {
+		build = append(build, "post", strconv.FormatUint(v.post, 10))
+	}
+	build = append(build, identifiersOf(v.local)...)
+
+	return newSemver(v.release, pre, build), nil
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// preKey orders the prerelease segment. Developmental releases of the final
+// release ("1.0.dev1") come before its alpha releases, and the final release
+// comes after all of them.
+func (v pep440Version) preKey() (int, uint64) {
+	switch {
+	case v.preKind == "" && !v.hasPost && v.hasDev:
+		return -1, 0
+	case v.preKind == "":
+		return len(pep440PreOrder), 0
+	default:
+		return pep440PreOrder[v.preKind], v.pre
+	}
+}
+
+func (PEP440) Compare(a, b string) int {
+	va, _ := parsePEP440(a)
+	vb, _ := parsePEP440(b)
+
+	if c := compareUint(va.epoch, vb.epoch); c != 0 {
+		return c
+	}
+	if c := compareNums(va.release, vb.release); c != 0 {
+		return c
+	}
+
+	ka, na := va.preKey()
+	kb, nb := vb.preKey()
+	if ka != kb {
+		return ka - kb
+	}
+	if c := compareUint(na, nb); c != 0 {
+		return c
+	}
+
+	// No post release comes before any post release.
+	if va.hasPost != vb.hasPost {
+		if va.hasPost {
+			return 1
+		}
+		return -1
+	}
+	if c := compareUint(va.post, vb.post); c != 0 {
+		return c
+	}
+
+	// Developmental releases come before the release they lead to.
+	if va.hasDev != vb.hasDev {
+		if va.hasDev {
+			return -1
+		}
+		return 1
+	}
+	if c := compareUint(va.dev, vb.dev); c != 0 {
+		return c
+	}
+
+	return strings.Compare(va.local, vb.local)
+}
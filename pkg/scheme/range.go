@@ -0,0 +1,65 @@
+package scheme
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Constraint is a comparison of versions against Version, such as ">=1.7.0".
+type Constraint struct {
+	// Op is one of "=", "!=", "<", "<=", ">" and ">=".
+	Op      string
+	Version string
+}
+
+// Range is a version range of a query, matched with the order of a Scheme
+// instead of semver. Versions match if they satisfy all the Constraints.
+type Range []Constraint
+
+var reConstraint = regexp.MustCompile(`^(!=|<=|>=|<|>|=)?(.+)$`)
+
+// ParseRange parses space separated constraints, e.g. ">=1.7.0 <1.8.0". A
+// constraint without an operator requires the version to be equal.
+func ParseRange(s string) (Range, error) {
+	var r Range
+	for _, f := range strings.Fields(s) {
+		ms := reConstraint.FindStringSubmatch(f)
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("Failed to parse version constraint %q", f)
+		}
+		op := ms[1]
+		if op == "" {
+			op = "="
+		}
+		r = append(r, Constraint{Op: op, Version: ms[2]})
+	}
+	return r, nil
+}
+
+// Match reports whether the version v of scheme s is in the range. A nil
+// range matches any version.
+func (r Range) Match(s Scheme, v string) bool {
+	for _, c := range r {
+		cmp := s.Compare(v, c.Version)
+		var ok bool
+		switch c.Op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
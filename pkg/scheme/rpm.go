@@ -0,0 +1,181 @@
+package scheme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// RPM orders versions of RPM packages: "[epoch:]version[-release]".
+// https://rpm-software-management.github.io/rpm/manual/dependencies.html#versioning
+type RPM struct{}
+
+type rpmVersion struct {
+	epoch   uint64
+	version string
+	release string
+}
+
+func parseRPM(s string) (rpmVersion, error) {
+	var v rpmVersion
+
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		epoch, err := strconv.ParseUint(s[:i], 10, 64)
+		if err != nil {
+			return rpmVersion{}, fmt.Errorf("Failed to parse epoch of RPM version %q: %w", s, err)
+		}
+		v.epoch = epoch
+		s = s[i+1:]
+	}
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		v.version, v.release = s[:i], s[i+1:]
+	} else {
+		v.version = s
+	}
+
+	if v.version == "" || !isDigit(v.version[0]) {
+		return rpmVersion{}, fmt.Errorf("Failed to parse RPM version %q", s)
+	}
+	return v, nil
+}
+
+func (RPM) Parse(s string) (semver.Version, error) {
+	v, err := parseRPM(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	numstr := reLeadingNums.FindString(v.version)
+	nums, err := parseNums(strings.Split(numstr, "."))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("Failed to parse RPM version %q: %w", s, err)
+	}
+
+	// "1.0~rc1" sorts before "1.0", "1.0^git1" after.
+	rest := v.version[len(numstr):]
+	var pre []semver.PRVersion
+	if i := strings.IndexByte(rest, '~'); i >= 0 {
+		pre = prVersionsOf(rest[i+1:])
+		rest = rest[:i]
+	}
+	build := append(identifiersOf(rest), identifiersOf(v.release)...)
+
+	return newSemver(nums, pre, build), nil
+}
+
+func isAlpha(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z')
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isAlpha(c)
+}
+
+// rpmCompareParts implements rpmvercmp() of rpm.
+func rpmCompareParts(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isAlnum(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isAlnum(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		// "~" sorts before anything, even the end of the string.
+		if (i < len(a) && a[i] == '~') || (j < len(b) && b[j] == '~') {
+			if i >= len(a) || a[i] != '~' {
+				return 1
+			}
+			if j >= len(b) || b[j] != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// "^" sorts after the end of the string, but before anything else.
+		if (i < len(a) && a[i] == '^') || (j < len(b) && b[j] == '^') {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		isNum := isDigit(a[i])
+		segOf := func(s string, k int) (string, int) {
+			start := k
+			for k < len(s) && ((isNum && isDigit(s[k])) || (!isNum && isAlpha(s[k]))) {
+				k++
+			}
+			return s[start:k], k
+		}
+		var sa, sb string
+		sa, i = segOf(a, i)
+		sb, j = segOf(b, j)
+
+		// Segments of different types: numeric is newer.
+		if sb == "" {
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		if isNum {
+			sa = strings.TrimLeft(sa, "0")
+			sb = strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				return len(sa) - len(sb)
+			}
+		}
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+
+	aDone, bDone := i >= len(a), j >= len(b)
+	switch {
+	case aDone && bDone:
+		return 0
+	case aDone:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (RPM) Compare(a, b string) int {
+	va, _ := parseRPM(a)
+	vb, _ := parseRPM(b)
+
+	if c := compareUint(va.epoch, vb.epoch); c != 0 {
+		return c
+	}
+	if c := rpmCompareParts(va.version, vb.version); c != 0 {
+		return c
+	}
+	return rpmCompareParts(va.release, vb.release)
+}
@@ -0,0 +1,200 @@
+package scheme
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// Scheme defines how the version strings of a software are parsed and
+// ordered, for software which does not follow semver.
+type Scheme interface {
+	// Parse maps the version string s to semver, for the output of the
+	// queries. It fails if s is not a version in the scheme.
+	Parse(s string) (semver.Version, error)
+	// Compare returns negative, zero or positive if a is older than, the
+	// same as or newer than b. Both must be Parse-able. Version ranges of
+	// queries are matched with it, see Range.
+	Compare(a, b string) int
+}
+
+var Schemes = map[string]Scheme{
+	"semver":  Semver{},
+	"calver":  Calver{},
+	"pep440":  PEP440{},
+	"debian":  Debian{},
+	"rpm":     RPM{},
+	"maven":   Maven{},
+	"git":     Git{},
+	"lexical": Lexical{},
+}
+
+func Get(name string) (Scheme, error) {
+	s, ok := Schemes[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown version scheme %q", name)
+	}
+	return s, nil
+}
+
+var reComponent = regexp.MustCompile(`^[^0-9]*`)
+
+// TrimComponent drops the component name prefixed to a version string, such
+// as "go" in "go1.15", "v" in "v1.2.3" or "cli/v" in "cli/v1.2.3".
+func TrimComponent(s string) string {
+	return reComponent.ReplaceAllString(s, "")
+}
+
+// newSemver constructs a semver from the leading numeric components nums.
+// Components past the third are kept as build metadata.
+func newSemver(nums []uint64, pre []semver.PRVersion, build []string) semver.Version {
+	var v semver.Version
+	for i, n := range nums {
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		default:
+			v.Build = append(v.Build, strconv.FormatUint(n, 10))
+		}
+	}
+	v.Pre = pre
+	v.Build = append(v.Build, build...)
+	return v
+}
+
+var reNonAlnumRun = regexp.MustCompile(`[^0-9A-Za-z]+`)
+
+func identifiersOf(s string) []string {
+	s = strings.Trim(reNonAlnumRun.ReplaceAllString(s, "."), ".")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ".")
+}
+
+// prVersionsOf splits s into semver prerelease identifiers.
+func prVersionsOf(s string) []semver.PRVersion {
+	var prs []semver.PRVersion
+	for _, id := range identifiersOf(s) {
+		pr, err := semver.NewPRVersion(id)
+		if err != nil {
+			// e.g. leading zeros in a numeric identifier
+			pr = semver.PRVersion{VersionStr: "x" + id}
+		}
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+func parseNums(ss []string) ([]uint64, error) {
+	nums := make([]uint64, 0, len(ss))
+	for _, s := range ss {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// compareNums compares the numeric components, treating missing ones as
+// zero.
+func compareNums(a, b []uint64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y uint64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Semver parses versions as semver, tolerating a missing minor or patch
+// version.
+type Semver struct{}
+
+func (Semver) Parse(s string) (semver.Version, error) {
+	return semver.ParseTolerant(s)
+}
+
+func (Semver) Compare(a, b string) int {
+	va, _ := semver.ParseTolerant(a)
+	vb, _ := semver.ParseTolerant(b)
+	return va.Compare(vb)
+}
+
+// Calver parses calendar versions such as "2024.03.1", "24.04" and
+// "20240315": any number of numeric components compared in order.
+type Calver struct{}
+
+var reCalver = regexp.MustCompile(`^\d+([.\-_]\d+)*$`)
+var reCalverSep = regexp.MustCompile(`[.\-_]`)
+
+func (Calver) nums(s string) ([]uint64, error) {
+	if !reCalver.MatchString(s) {
+		return nil, fmt.Errorf("Failed to parse calendar version %q", s)
+	}
+	return parseNums(reCalverSep.Split(s, -1))
+}
+
+func (c Calver) Parse(s string) (semver.Version, error) {
+	nums, err := c.nums(s)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return newSemver(nums, nil, nil), nil
+}
+
+func (c Calver) Compare(a, b string) int {
+	na, _ := c.nums(a)
+	nb, _ := c.nums(b)
+	return compareNums(na, nb)
+}
+
+// Lexical orders versions as plain strings. Versions are mapped to semver
+// only as far as semver.ParseTolerant can, and to 0.0.0 otherwise.
+type Lexical struct{}
+
+func (Lexical) Parse(s string) (semver.Version, error) {
+	v, err := semver.ParseTolerant(s)
+	if err != nil {
+		return semver.Version{}, nil
+	}
+	return v, nil
+}
+
+func (Lexical) Compare(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// Maven orders versions as Maven does. See ComparableVersion.
+type Maven struct{}
+
+func (Maven) Parse(s string) (semver.Version, error) {
+	v, ok := MavenToSemver(s, ParseComparableVersion(s).IsPrerelease())
+	if !ok {
+		return semver.Version{}, fmt.Errorf("Failed to parse Maven version %q", s)
+	}
+	return v, nil
+}
+
+func (Maven) Compare(a, b string) int {
+	return ParseComparableVersion(a).Compare(ParseComparableVersion(b))
+}
@@ -0,0 +1,138 @@
+package scheme
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	testcases := []struct {
+		scheme  string
+		ordered []string
+	}{
+		{"semver", []string{"1.0.0-rc.1", "1.0.0", "1.2", "1.10.0"}},
+		{"calver", []string{"23.10", "24.04", "24.04.1", "2024.03.1", "20240315"}},
+		{"pep440", []string{
+			"1.0.dev1", "1.0a1.dev1", "1.0a1", "1.0a2", "1.0b1", "1.0rc1", "1.0",
+			"1.0.post1.dev1", "1.0.post1", "1.0-2", "1.1.dev1", "1.1", "1!0.1",
+		}},
+		{"debian", []string{
+			"1.0~rc1", "1.0", "1.0-1", "1.0-1ubuntu1", "1.0a", "1.0+dfsg-1", "1.0.1", "1.10", "1:0.9",
+		}},
+		{"rpm", []string{
+			"1.0~rc1", "1.0", "1.0^git1", "1.0a", "1.0.1", "1.0.1-2", "1.0.1-10.el9", "1.10", "1:0.9",
+		}},
+		{"maven", []string{"1.0-alpha-1", "1.0-RC1", "1.0", "1.0.1", "1.0.10"}},
+		{"git", []string{"1.2.3-rc.1", "1.2.3", "1.2.3-4-g1234abc", "1.2.3-10-gabcdef0", "1.2.4"}},
+		{"lexical", []string{"100", "25", "9"}},
+	}
+
+	for _, tc := range testcases {
+		s, err := Get(tc.scheme)
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", tc.scheme, err)
+		}
+		for _, v := range tc.ordered {
+			if _, err := s.Parse(v); err != nil {
+				t.Errorf("%s: Parse(%q) failed: %v", tc.scheme, v, err)
+			}
+		}
+		for i := 0; i < len(tc.ordered); i++ {
+			for j := i + 1; j < len(tc.ordered); j++ {
+				a, b := tc.ordered[i], tc.ordered[j]
+				if c := s.Compare(a, b); c >= 0 {
+					t.Errorf("%s: Expected %q < %q, got %d", tc.scheme, a, b, c)
+				}
+				if c := s.Compare(b, a); c <= 0 {
+					t.Errorf("%s: Expected %q > %q, got %d", tc.scheme, b, a, c)
+				}
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		scheme   string
+		input    string
+		expected string
+	}{
+		{"calver", "2024.03.1", "2024.3.1"},
+		{"calver", "1.2.3.4", "1.2.3+4"},
+		{"pep440", "1.0rc1", "1.0.0-rc.1"},
+		{"pep440", "2.1.post3", "2.1.0+post.3"},
+		{"debian", "1:2.36.1-8ubuntu1", "2.36.1+8ubuntu1"},
+		{"debian", "1.0~rc1-1", "1.0.0-rc1+1"},
+		{"rpm", "2.34-83.el9", "2.34.0+83.el9"},
+		{"maven", "5.4.2.Final", "5.4.2+final"},
+		{"git", "1.2.3-4-g1234abc", "1.2.3+4.g1234abc"},
+		{"git", "1.2.3-rc.1", "1.2.3-rc.1"},
+	}
+
+	for _, tc := range testcases {
+		s, err := Get(tc.scheme)
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", tc.scheme, err)
+		}
+		v, err := s.Parse(tc.input)
+		if err != nil {
+			t.Errorf("%s: Parse(%q) failed: %v", tc.scheme, tc.input, err)
+			continue
+		}
+		if v.String() != tc.expected {
+			t.Errorf("%s: Parse(%q) expected %s, got %s", tc.scheme, tc.input, tc.expected, v)
+		}
+	}
+
+	if _, err := (Calver{}).Parse("2024.03-rc1"); err == nil {
+		t.Errorf("Expected calver Parse to fail on a qualifier")
+	}
+}
+
+func TestTrimComponent(t *testing.T) {
+	for input, expected := range map[string]string{
+		"go1.15":     "1.15",
+		"v1.2.3":     "1.2.3",
+		"cli/v1.2.3": "1.2.3",
+		"r25":        "25",
+		"1:2.3-1":    "1:2.3-1",
+	} {
+		if actual := TrimComponent(input); actual != expected {
+			t.Errorf("TrimComponent(%q) expected %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	testcases := []struct {
+		scheme  string
+		vrange  string
+		matched []string
+		other   []string
+	}{
+		{"calver", ">=25.0.0 <26.0.0", []string{"25", "25.1"}, []string{"24", "26"}},
+		{"git", ">=1.2.0 <1.3.0", []string{"1.2.3", "1.2.3-4-g1234abc"}, []string{"1.1.9-2-g1234abc", "1.3.0", "1.3.0-2-g1234abc"}},
+		{"git", "=1.2.3", []string{"1.2.3"}, []string{"1.2.3-4-g1234abc"}},
+		{"pep440", "<1.0.0", []string{"1.0rc1"}, []string{"1.0"}},
+	}
+
+	for _, tc := range testcases {
+		s, err := Get(tc.scheme)
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", tc.scheme, err)
+		}
+		r, err := ParseRange(tc.vrange)
+		if err != nil {
+			t.Fatalf("ParseRange(%q) failed: %v", tc.vrange, err)
+		}
+		for _, v := range tc.matched {
+			if !r.Match(s, v) {
+				t.Errorf("%s: Expected %q to match %q", tc.scheme, v, tc.vrange)
+			}
+		}
+		for _, v := range tc.other {
+			if r.Match(s, v) {
+				t.Errorf("%s: Expected %q not to match %q", tc.scheme, v, tc.vrange)
+			}
+		}
+	}
+}